@@ -0,0 +1,60 @@
+package opened
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// A QuestionResponse is a student's answer to one question of an assessment run
+type QuestionResponse struct {
+	ID              int
+	AssessmentRunID int       `json:"assessment_run_id"`
+	QuestionID      int       `json:"question_id"`
+	Answer          string    `json:"answer"`
+	Correct         bool      `json:"correct"`
+	Score           float32   `json:"score"`
+	AnsweredAt      time.Time `json:"answered_at"`
+}
+
+// listAssessmentRuns fetches the assessment runs matching params
+func (c *Client) listAssessmentRuns(ctx context.Context, params url.Values) ([]AssessmentRun, error) {
+	var data struct {
+		AssessmentRuns []AssessmentRun `json:"assessment_runs"`
+	}
+	if err := c.get(ctx, "/1/assessment_runs.json", params, &data); err != nil {
+		glog.Errorf("Error retrieving runs: %+v", err)
+		return nil, err
+	}
+	glog.V(1).Infof("Retrieved %d runs", len(data.AssessmentRuns))
+	return data.AssessmentRuns, nil
+}
+
+// StudentAssessmentRuns fetches the assessment runs of a student from the partner API
+func (c *Client) StudentAssessmentRuns(ctx context.Context, studentID int) ([]AssessmentRun, error) {
+	p := url.Values{}
+	p.Set("student_id", strconv.Itoa(studentID))
+	return c.listAssessmentRuns(ctx, p)
+}
+
+// ClassAssessmentRuns fetches the assessment runs of every student in a class from the partner API
+func (c *Client) ClassAssessmentRuns(ctx context.Context, classID int) ([]AssessmentRun, error) {
+	p := url.Values{}
+	p.Set("class_id", strconv.Itoa(classID))
+	return c.listAssessmentRuns(ctx, p)
+}
+
+// AssessmentRunResponses fetches the per-question results of an assessment run
+func (c *Client) AssessmentRunResponses(ctx context.Context, runID int) ([]QuestionResponse, error) {
+	var data struct {
+		Responses []QuestionResponse `json:"responses"`
+	}
+	if err := c.get(ctx, "/1/assessment_runs/"+strconv.Itoa(runID)+"/responses.json", nil, &data); err != nil {
+		glog.Errorf("Error retrieving responses for run %d: %+v", runID, err)
+		return nil, err
+	}
+	return data.Responses, nil
+}
@@ -0,0 +1,44 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAssessmentRuns(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/1/assessment_runs.json?student_id=9", "/1/assessment_runs.json?class_id=5":
+			w.Write([]byte(`{"assessment_runs":[{"id":3,"user_id":9,"assessment_id":4,"score":0.75,"first_run":true,"finished_at":"2016-05-10T17:54:28Z"}]}`))
+		case "/1/assessment_runs/3/responses.json?":
+			w.Write([]byte(`{"responses":[{"id":1,"assessment_run_id":3,"question_id":8,"answer":"B","correct":true,"score":1}]}`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	ctx := context.Background()
+
+	studentRuns, err := c.StudentAssessmentRuns(ctx, 9)
+	if err != nil {
+		t.Fatalf("Error from StudentAssessmentRuns: %+v", err)
+	}
+	classRuns, err := c.ClassAssessmentRuns(ctx, 5)
+	if err != nil {
+		t.Fatalf("Error from ClassAssessmentRuns: %+v", err)
+	}
+	for _, runs := range [][]AssessmentRun{studentRuns, classRuns} {
+		if len(runs) != 1 || runs[0].UserID != 9 || runs[0].Score != 0.75 || !runs[0].FirstRun || runs[0].FinishedAt.IsZero() {
+			t.Errorf("Got runs %+v", runs)
+		}
+	}
+	responses, err := c.AssessmentRunResponses(ctx, 3)
+	if err != nil || len(responses) != 1 || !responses[0].Correct || responses[0].QuestionID != 8 {
+		t.Errorf("Got responses %+v, error %+v", responses, err)
+	}
+}
@@ -0,0 +1,205 @@
+package opened
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+// The states of a CircuitBreaker.
+const (
+	// BreakerClosed lets every request through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every request at once with ErrBreakerOpen.
+	BreakerOpen
+	// BreakerHalfOpen lets one probe request through to see whether the
+	// partner has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrBreakerOpen is returned for requests a CircuitBreaker does not let through.
+var ErrBreakerOpen = errors.New("opened: circuit breaker open")
+
+// Defaults for a CircuitBreaker.
+const (
+	DefaultBreakerFailures = 5
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// A CircuitBreaker stops a Client sending requests to a partner API which
+// keeps failing.  After Failures consecutive failures it opens and fails
+// requests at once; after Cooldown it lets a probe through, closing again if
+// the probe succeeds.  Transport errors and 5xx responses are failures.  It is
+// safe for concurrent use and may be shared between clients.
+type CircuitBreaker struct {
+	// Failures is how many consecutive failures open the breaker.  If 0
+	// DefaultBreakerFailures is used.
+	Failures int
+	// Cooldown is how long the breaker stays open before a probe.  If 0
+	// DefaultBreakerCooldown is used.
+	Cooldown time.Duration
+	// OnStateChange, if set, is called whenever the state changes, e.g. to
+	// switch to a database or cached Catalog.  It must not call the breaker.
+	OnStateChange func(from, to BreakerState)
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	// generation counts state changes, so outcomes of requests let through
+	// in an earlier state can be ignored.
+	generation uint64
+}
+
+// State returns the breaker's state.  An open breaker whose cooldown is over
+// reports BreakerHalfOpen.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown > 0 {
+		return b.Cooldown
+	}
+	return DefaultBreakerCooldown
+}
+
+func (b *CircuitBreaker) maxFailures() int {
+	if b.Failures > 0 {
+		return b.Failures
+	}
+	return DefaultBreakerFailures
+}
+
+// setState changes the state.  b.mu must be held.
+func (b *CircuitBreaker) setState(to BreakerState) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	b.generation++
+	b.probing = false
+	if to == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+	glog.V(1).Infof("Circuit breaker %s", to)
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
+
+// allow reports whether a request may be sent.  If it returns nil the caller
+// must report the outcome with done, passing the generation it returned.
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown() {
+		b.setState(BreakerHalfOpen)
+	}
+	switch {
+	case b.state == BreakerOpen, b.state == BreakerHalfOpen && b.probing:
+		return 0, ErrBreakerOpen
+	case b.state == BreakerHalfOpen:
+		b.probing = true
+	}
+	return b.generation, nil
+}
+
+// outcome is how a request allowed by a CircuitBreaker ended.
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	// abandoned requests were given up by the caller and say nothing
+	// about the partner.
+	abandoned
+)
+
+// done records the outcome of a request allow let through in generation gen.
+// Outcomes of requests let through before the last state change are ignored,
+// so a slow success cannot close a breaker which has opened since, and only
+// the probe of a half-open breaker decides whether it closes.
+func (b *CircuitBreaker) done(gen uint64, o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.generation {
+		return
+	}
+	probe := b.state == BreakerHalfOpen
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case o == abandoned:
+	case o == succeeded:
+		b.failures = 0
+		b.setState(BreakerClosed)
+	case probe:
+		b.setState(BreakerOpen)
+	default:
+		b.failures++
+		if b.failures >= b.maxFailures() {
+			b.failures = 0
+			b.setState(BreakerOpen)
+		}
+	}
+}
+
+// Transport returns a RoundTripper sending requests through next, or
+// http.DefaultTransport if next is nil, while the breaker lets them through.
+// A Client puts it below its Cache, so cached responses are served whatever
+// the state of the breaker and say nothing about the partner.
+func (b *CircuitBreaker) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &breakerTransport{breaker: b, next: next}
+}
+
+type breakerTransport struct {
+	breaker *CircuitBreaker
+	next    http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	gen, err := t.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		t.breaker.done(gen, abandoned)
+	case err != nil, resp.StatusCode >= 500:
+		t.breaker.done(gen, failed)
+	default:
+		t.breaker.done(gen, succeeded)
+	}
+	return resp, err
+}
@@ -0,0 +1,160 @@
+package opened
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker opens the breaker on a failing partner, checks requests
+// fail fast, then lets probes through until the partner recovers
+func TestCircuitBreaker(t *testing.T) {
+	var down, hits int32 = 1, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"standard_groups":[]}`))
+	}))
+	defer ts.Close()
+	var changes []string
+	b := &CircuitBreaker{Failures: 2, Cooldown: 20 * time.Millisecond}
+	b.OnStateChange = func(from, to BreakerState) {
+		changes = append(changes, from.String()+"->"+to.String())
+	}
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &NoRetry
+	c.Breaker = b
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListStandardGroups(ctx); err == nil || errors.Is(err, ErrBreakerOpen) {
+			t.Errorf("Got error %+v from failing partner", err)
+		}
+	}
+	if b.State() != BreakerOpen {
+		t.Errorf("Breaker %s after 2 failures", b.State())
+	}
+	if _, err := c.ListStandardGroups(ctx); !errors.Is(err, ErrBreakerOpen) || atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Got error %+v and %d requests with the breaker open", err, hits)
+	}
+
+	// a failed probe opens the breaker again
+	time.Sleep(25 * time.Millisecond)
+	if b.State() != BreakerHalfOpen {
+		t.Errorf("Breaker %s after cooldown", b.State())
+	}
+	if _, err := c.ListStandardGroups(ctx); err == nil || errors.Is(err, ErrBreakerOpen) || atomic.LoadInt32(&hits) != 3 {
+		t.Errorf("Got error %+v and %d requests from probe", err, hits)
+	}
+	if b.State() != BreakerOpen {
+		t.Errorf("Breaker %s after failed probe", b.State())
+	}
+
+	atomic.StoreInt32(&down, 0)
+	time.Sleep(25 * time.Millisecond)
+	if _, err := c.ListStandardGroups(ctx); err != nil {
+		t.Errorf("Error from probe of recovered partner: %+v", err)
+	}
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if b.State() != BreakerClosed || !reflect.DeepEqual(changes, want) {
+		t.Errorf("Breaker %s after changes %v", b.State(), changes)
+	}
+}
+
+// TestBreakerBelowCache checks cached responses are served while the breaker
+// is open and neither close it nor reset its failure count
+func TestBreakerBelowCache(t *testing.T) {
+	var down, hits int32 = 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write([]byte(`{"standard_groups":[{"id":1}]}`))
+	}))
+	defer ts.Close()
+	b := &CircuitBreaker{Failures: 2, Cooldown: 20 * time.Millisecond}
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &NoRetry
+	c.Cache = NewCache(NewMemoryCache(10))
+	c.Breaker = b
+	ctx := context.Background()
+
+	if _, err := c.ListStandardGroups(ctx); err != nil {
+		t.Fatalf("Error from ListStandardGroups: %+v", err)
+	}
+	atomic.StoreInt32(&down, 1)
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListGradeGroups(ctx, 1); err == nil || errors.Is(err, ErrBreakerOpen) {
+			t.Errorf("Got error %+v from failing partner", err)
+		}
+		if _, err := c.ListStandardGroups(ctx); err != nil {
+			t.Errorf("Error from cached ListStandardGroups: %+v", err)
+		}
+	}
+	if b.State() != BreakerOpen || atomic.LoadInt32(&hits) != 3 {
+		t.Errorf("Breaker %s after %d requests", b.State(), hits)
+	}
+	if _, err := c.ListGradeGroups(ctx, 1); !errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("Got error %+v with the breaker open", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, err := c.ListStandardGroups(ctx); err != nil || b.State() != BreakerHalfOpen {
+		t.Errorf("Breaker %s after cached response, error %+v", b.State(), err)
+	}
+	if _, err := c.ListGradeGroups(ctx, 1); err == nil || b.State() != BreakerOpen || atomic.LoadInt32(&hits) != 4 {
+		t.Errorf("Breaker %s after probe, error %+v", b.State(), err)
+	}
+}
+
+// TestBreakerStaleSuccess checks a slow request let through before the breaker
+// opened cannot close it by succeeding afterwards
+func TestBreakerStaleSuccess(t *testing.T) {
+	arrived, release := make(chan bool), make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1/standard_groups.json" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		arrived <- true
+		<-release
+		w.Write([]byte(`{"standard_groups":[]}`))
+	}))
+	defer ts.Close()
+	b := &CircuitBreaker{Failures: 1, Cooldown: time.Minute}
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &NoRetry
+	c.Breaker = b
+	ctx := context.Background()
+
+	slow := make(chan error)
+	go func() {
+		_, err := c.ListStandardGroups(ctx)
+		slow <- err
+	}()
+	<-arrived
+	if _, err := c.ListGradeGroups(ctx, 1); err == nil || b.State() != BreakerOpen {
+		t.Errorf("Breaker %s after failure, error %+v", b.State(), err)
+	}
+	close(release)
+	if err := <-slow; err != nil {
+		t.Errorf("Error from slow request: %+v", err)
+	}
+	if b.State() != BreakerOpen {
+		t.Errorf("Breaker %s after a success admitted before it opened", b.State())
+	}
+}
@@ -0,0 +1,142 @@
+package opened
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultBulkWorkers is how many fetches a bulk fetch runs at once unless
+// BulkOptions says otherwise.
+const DefaultBulkWorkers = 4
+
+// BulkOptions control how a bulk fetch such as Client.FetchResources runs.
+type BulkOptions struct {
+	// Workers is how many fetches run at once.  If 0 DefaultBulkWorkers is used.
+	Workers int
+	// Rate is the most fetches started per second across all workers.
+	// If 0 fetches are started as fast as workers are free.
+	Rate float64
+}
+
+// run calls fetch for each index below n on a pool of workers and returns the
+// error of each call, in index order.  Once ctx is done the indexes not yet
+// started fail with ctx.Err().
+func (o BulkOptions) run(ctx context.Context, n int, fetch func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	workers := o.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+	if workers > n {
+		workers = n
+	}
+	var limiter *RateLimiter
+	if o.Rate > 0 {
+		limiter = NewRateLimiter(o.Rate, 1)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = fetch(ctx, i)
+			}
+		}()
+	}
+	i := 0
+feed:
+	for ; i < n; i++ {
+		if limiter != nil && limiter.Wait(ctx) != nil {
+			break feed
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	for ; i < n; i++ {
+		errs[i] = ctx.Err()
+	}
+	return errs
+}
+
+// FetchResources fetches the resources with the given IDs from the partner
+// API, several at a time as opts allows.  The resources are returned in the
+// order of IDs, with a zero WsResource for each that failed, along with the
+// error for each ID that failed.  The errors are nil if every fetch succeeded.
+func (c *Client) FetchResources(ctx context.Context, IDs []int, opts BulkOptions) ([]WsResource, map[int]error) {
+	resources := make([]WsResource, len(IDs))
+	errs := opts.run(ctx, len(IDs), func(ctx context.Context, i int) error {
+		var err error
+		resources[i], err = c.GetResource(ctx, IDs[i])
+		return err
+	})
+	return resources, intErrors(IDs, errs)
+}
+
+// FetchStandards fetches the standards with the given identifiers from the
+// partner API like FetchResources.
+func (c *Client) FetchStandards(ctx context.Context, identifiers []string, opts BulkOptions) ([]Standard, map[string]error) {
+	standards := make([]Standard, len(identifiers))
+	errs := opts.run(ctx, len(identifiers), func(ctx context.Context, i int) error {
+		var err error
+		standards[i], err = c.GetStandard(ctx, identifiers[i])
+		return err
+	})
+	var byID map[string]error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if byID == nil {
+			byID = map[string]error{}
+		}
+		byID[identifiers[i]] = err
+	}
+	return standards, byID
+}
+
+// FetchResources fills in the resources with the given IDs from the database,
+// several at a time as opts allows, like Client.FetchResources.
+func FetchResources(ctx context.Context, db sqlx.DB, IDs []int, opts BulkOptions) ([]Resource, map[int]error) {
+	resources := make([]Resource, len(IDs))
+	errs := opts.run(ctx, len(IDs), func(ctx context.Context, i int) error {
+		resources[i].ID = IDs[i]
+		return resources[i].GetResourceContext(ctx, db)
+	})
+	return resources, intErrors(IDs, errs)
+}
+
+// FetchStandards fills in the standards with the given IDs from the database
+// like FetchResources.
+func FetchStandards(ctx context.Context, db sqlx.DB, IDs []int, opts BulkOptions) ([]Standard, map[int]error) {
+	standards := make([]Standard, len(IDs))
+	errs := opts.run(ctx, len(IDs), func(ctx context.Context, i int) error {
+		standards[i].ID = IDs[i]
+		return standards[i].GetStandardContext(ctx, db)
+	})
+	return standards, intErrors(IDs, errs)
+}
+
+// intErrors maps each of IDs to its error in errs, leaving out successes.
+func intErrors(IDs []int, errs []error) map[int]error {
+	var byID map[int]error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if byID == nil {
+			byID = map[int]error{}
+		}
+		byID[IDs[i]] = err
+	}
+	return byID
+}
@@ -0,0 +1,68 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFetchResources checks bulk fetches keep input order, report each
+// failure and never run more than the allowed number of workers
+func TestFetchResources(t *testing.T) {
+	var mu sync.Mutex
+	running, most := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/1/resources/"), ".json")
+		if id == "13" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"resource":{"id":` + id + `}}`))
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+
+	IDs := []int{5, 13, 1, 9, 2, 8, 7, 3}
+	resources, errs := c.FetchResources(context.Background(), IDs, BulkOptions{Workers: 3})
+	for i, r := range resources {
+		if IDs[i] != 13 && r.ID != IDs[i] {
+			t.Errorf("Resource %d is %+v, expected ID %d", i, r, IDs[i])
+		}
+	}
+	if len(errs) != 1 || !IsNotFound(errs[13]) {
+		t.Errorf("Got errors %+v", errs)
+	}
+	if most > 3 {
+		t.Errorf("%d fetches ran at once", most)
+	}
+
+	start := time.Now()
+	if _, errs = c.FetchResources(context.Background(), []int{1, 2, 3}, BulkOptions{Rate: 50}); errs != nil {
+		t.Errorf("Got errors %+v", errs)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("3 fetches at 50 a second took %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errs = c.FetchResources(ctx, []int{1, 2}, BulkOptions{})
+	if len(errs) != 2 {
+		t.Errorf("Got errors %+v after cancel", errs)
+	}
+}
@@ -0,0 +1,357 @@
+package opened
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// A CacheStore holds cached responses.  It must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// CacheStats counts how requests were answered by a Cache.
+type CacheStats struct {
+	// Hits were answered from the cache without asking the partner.
+	Hits int64
+	// Revalidations were answered from the cache after the partner said 304 Not Modified.
+	Revalidations int64
+	// Misses had to be fetched from the partner.
+	Misses int64
+}
+
+// A Cache keeps partner API responses and answers repeated GET requests from
+// them, honoring Cache-Control and Expires and revalidating stale responses
+// with If-None-Match and If-Modified-Since.  Set it as Client.Cache.
+type Cache struct {
+	Store CacheStore
+	// Cacheable picks the requests which may be cached.  If nil only the
+	// catalog endpoints, which are the same for every user, are cached.
+	// Resource searches are left out, as cached responses are held in
+	// memory whole and search pages are streamed to keep memory bounded.
+	Cacheable func(req *http.Request) bool
+
+	hits          int64
+	revalidations int64
+	misses        int64
+}
+
+// NewCache returns a Cache keeping responses in store.
+func NewCache(store CacheStore) *Cache {
+	return &Cache{Store: store}
+}
+
+// Stats returns the cache's hit and miss counts so far.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:          atomic.LoadInt64(&c.hits),
+		Revalidations: atomic.LoadInt64(&c.revalidations),
+		Misses:        atomic.LoadInt64(&c.misses),
+	}
+}
+
+// catalogPaths are the endpoints whose responses do not depend on the user,
+// apart from resource searches.
+var catalogPaths = []string{
+	"/1/resources/",
+	"/1/standard_groups.json",
+	"/1/grade_groups.json",
+	"/1/categories.json",
+	"/1/standards",
+}
+
+func isCatalogRequest(req *http.Request) bool {
+	for _, p := range catalogPaths {
+		if strings.HasPrefix(req.URL.Path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Transport returns a RoundTripper which answers from the cache where it can
+// and sends other requests through next, or http.DefaultTransport if next is nil.
+func (c *Cache) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cacheTransport{cache: c, next: next}
+}
+
+type cacheTransport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+// cacheEntry is what is kept in a CacheStore for one response.
+type cacheEntry struct {
+	StoredAt time.Time `json:"stored_at"`
+	Response []byte    `json:"response"`
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cacheable := t.cache.Cacheable
+	if cacheable == nil {
+		cacheable = isCatalogRequest
+	}
+	if req.Method != "GET" || !cacheable(req) {
+		return t.next.RoundTrip(req)
+	}
+	key := req.URL.String()
+	entry, cached := t.load(key, req)
+	if cached != nil && fresh(cached.Header, entry.StoredAt) {
+		atomic.AddInt64(&t.cache.hits, 1)
+		glog.V(2).Infof("Cache hit for %s", key)
+		return cached, nil
+	}
+	if cached != nil {
+		// ask the partner whether our copy is still good
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		for _, h := range []string{"Cache-Control", "Expires", "ETag", "Last-Modified", "Date"} {
+			if v := resp.Header.Get(h); v != "" {
+				cached.Header.Set(h, v)
+			}
+		}
+		atomic.AddInt64(&t.cache.revalidations, 1)
+		glog.V(2).Infof("Cache revalidated %s", key)
+		return t.store(key, cached)
+	}
+	atomic.AddInt64(&t.cache.misses, 1)
+	if resp.StatusCode == http.StatusOK && storable(resp.Header) {
+		return t.store(key, resp)
+	}
+	return resp, nil
+}
+
+// load returns the cached entry and response for key, if any.
+func (t *cacheTransport) load(key string, req *http.Request) (cacheEntry, *http.Response) {
+	var entry cacheEntry
+	data, ok := t.cache.Store.Get(key)
+	if !ok {
+		return entry, nil
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.cache.Store.Delete(key)
+		return entry, nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry.Response)), req)
+	if err != nil {
+		t.cache.Store.Delete(key)
+		return entry, nil
+	}
+	return entry, resp
+}
+
+// store saves resp under key and returns an equivalent response whose body
+// has not been read yet.
+func (t *cacheTransport) store(key string, resp *http.Response) (*http.Response, error) {
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := json.Marshal(cacheEntry{StoredAt: time.Now(), Response: data})
+	if err == nil {
+		t.cache.Store.Set(key, entry)
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), resp.Request)
+}
+
+// cacheControl parses a Cache-Control header into its directives.
+func cacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, part := range strings.Split(h.Get("Cache-Control"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		v := ""
+		if len(kv) == 2 {
+			v = strings.Trim(kv[1], `"`)
+		}
+		cc[strings.ToLower(kv[0])] = v
+	}
+	return cc
+}
+
+// lifetime returns how long a response with header h stays fresh.
+func lifetime(h http.Header) time.Duration {
+	cc := cacheControl(h)
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if v, ok := cc["max-age"]; ok {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if v := h.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		date, err := http.ParseTime(h.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+		return expires.Sub(date)
+	}
+	return 0
+}
+
+// fresh reports whether a response with header h stored at storedAt may be used without revalidation.
+func fresh(h http.Header, storedAt time.Time) bool {
+	return time.Since(storedAt) < lifetime(h)
+}
+
+// storable reports whether a response with header h is worth keeping.
+func storable(h http.Header) bool {
+	if _, ok := cacheControl(h)["no-store"]; ok {
+		return false
+	}
+	return lifetime(h) > 0 || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// A MemoryCache is a CacheStore keeping the most recently used entries in memory.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type memoryEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache returns a MemoryCache holding at most maxEntries entries.
+// If maxEntries is 0 there is no limit.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{maxEntries: maxEntries, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// Get returns the entry for key and marks it as recently used.
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.lru.MoveToFront(e)
+	return e.Value.(*memoryEntry).value, true
+}
+
+// Set stores value under key, evicting the least recently used entry if full.
+func (m *MemoryCache) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		e.Value.(*memoryEntry).value = value
+		m.lru.MoveToFront(e)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(&memoryEntry{key, value})
+	if m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// Delete removes the entry for key.
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		m.lru.Remove(e)
+		delete(m.entries, key)
+	}
+}
+
+// A DiskCache is a CacheStore keeping one file per entry in a directory, so
+// cached responses survive restarts.
+type DiskCache struct {
+	Dir string
+}
+
+// NewDiskCache returns a DiskCache in dir, creating it if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the entry for key.
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set writes value for key, replacing the file atomically.
+func (d *DiskCache) Set(key string, value []byte) {
+	f, err := ioutil.TempFile(d.Dir, "tmp-")
+	if err != nil {
+		glog.Errorf("Error writing cache entry: %+v", err)
+		return
+	}
+	_, err = f.Write(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), d.path(key))
+	}
+	if err != nil {
+		glog.Errorf("Error writing cache entry: %+v", err)
+		os.Remove(f.Name())
+	}
+}
+
+// Delete removes the entry for key.
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
@@ -0,0 +1,107 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLifetime(t *testing.T) {
+	now := time.Now().UTC()
+	for _, tc := range []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Cache-Control": {"public, max-age=60"}}, time.Minute},
+		{http.Header{"Cache-Control": {"max-age=60, no-cache"}}, 0},
+		{http.Header{"Date": {now.Format(http.TimeFormat)}, "Expires": {now.Add(time.Hour).Format(http.TimeFormat)}}, time.Hour},
+		{http.Header{"ETag": {`"v1"`}}, 0},
+	} {
+		if got := lifetime(tc.header); got != tc.want {
+			t.Errorf("Lifetime of %+v is %s, expected %s", tc.header, got, tc.want)
+		}
+	}
+	if storable(http.Header{"Cache-Control": {"no-store"}, "ETag": {`"v1"`}}) {
+		t.Errorf("no-store response is storable")
+	}
+}
+
+func TestIsCatalogRequest(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/1/resources/7.json":     true,
+		"/1/standard_groups.json": true,
+		"/1/resources.json":       false,
+		"/1/users.json":           false,
+	} {
+		req, _ := http.NewRequest("GET", "https://partner.opened.com"+path, nil)
+		if got := isCatalogRequest(req); got != want {
+			t.Errorf("%s is cacheable: %t", path, got)
+		}
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	m := NewMemoryCache(2)
+	m.Set("a", []byte("1"))
+	m.Set("b", []byte("2"))
+	m.Get("a")
+	m.Set("c", []byte("3"))
+	if _, ok := m.Get("b"); ok {
+		t.Errorf("Least recently used entry not evicted")
+	}
+	if v, ok := m.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Recently used entry evicted")
+	}
+}
+
+// TestCache checks fresh hits, ETag revalidation and misses against both stores
+func TestCache(t *testing.T) {
+	var version, fetches, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v` + strconv.Itoa(version) + `"`
+		w.Header().Set("ETag", etag)
+		if r.URL.Path == "/1/standard_groups.json" {
+			w.Header().Set("Cache-Control", "max-age=3600")
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches++
+		w.Write([]byte(`{"standard_groups":[{"id":1}],"grade_groups":[{"id":` + strconv.Itoa(version) + `}]}`))
+	}))
+	defer ts.Close()
+
+	disk, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %+v", err)
+	}
+	for _, store := range []CacheStore{NewMemoryCache(10), disk} {
+		version, fetches, notModified = 1, 0, 0
+		c := NewClient(ts.URL, "", "", "")
+		c.TokenSource = StaticTokenSource("token")
+		c.Cache = NewCache(store)
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			if _, err := c.ListStandardGroups(ctx); err != nil {
+				t.Fatalf("Error from ListStandardGroups: %+v", err)
+			}
+			groups, err := c.ListGradeGroups(ctx, 1)
+			if err != nil || groups.GradeGroups[0].ID != version {
+				t.Fatalf("Got grade groups %+v, error %+v", groups, err)
+			}
+			if i == 1 {
+				version = 2
+			}
+		}
+		want := CacheStats{Hits: 2, Revalidations: 1, Misses: 3}
+		if got := c.Cache.Stats(); got != want || fetches != 3 || notModified != 1 {
+			t.Errorf("%T: stats %+v with %d fetches and %d not modified", store, got, fetches, notModified)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package opened
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/jmoiron/sqlx"
+)
+
+// A Catalog reads resources and standards whatever their source, so the same
+// code can run with database access, with partner API credentials or both.
+// *Client is the partner API Catalog, DBCatalog reads the database and
+// FallbackCatalog tries one and then another.
+type Catalog interface {
+	GetResource(ctx context.Context, ID int) (WsResource, error)
+	// GetStandard returns the standard with the given identifier, such as K.CC.1.
+	GetStandard(ctx context.Context, identifier string) (Standard, error)
+	// ListAlignments lists the standards a resource is aligned to.
+	ListAlignments(ctx context.Context, resourceID int) ([]Standard, error)
+	Search(ctx context.Context, q SearchQuery) (ResourceList, error)
+}
+
+// ErrUnsupportedQuery is returned by a Catalog which cannot run a search.
+var ErrUnsupportedQuery = errors.New("opened: query not supported by this catalog")
+
+var (
+	_ Catalog = (*Client)(nil)
+	_ Catalog = DBCatalog{}
+	_ Catalog = FallbackCatalog{}
+)
+
+// ListAlignments lists the standards the resource with the given ID is aligned to
+func (c *Client) ListAlignments(ctx context.Context, resourceID int) ([]Standard, error) {
+	r, err := c.GetResource(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return r.Standards, nil
+}
+
+// A DBCatalog is a Catalog reading the OpenEd database.
+type DBCatalog struct {
+	DB sqlx.DB
+}
+
+// standardColumns are the standards columns read into a Standard.
+const standardColumns = "standards.ID,standards.Identifier,standards.Grade,standards.Title,standards.Description"
+
+// GetResource returns the resource with the given ID.  A missing resource is
+// reported as sql.ErrNoRows, for which IsNotFound is true.
+func (d DBCatalog) GetResource(ctx context.Context, ID int) (WsResource, error) {
+	resource := Resource{ID: ID}
+	if err := resource.GetResourceContext(ctx, d.DB); err != nil {
+		return WsResource{}, err
+	}
+	return resource.ToWsResource(), nil
+}
+
+// GetStandard returns the standard with the given identifier.
+func (d DBCatalog) GetStandard(ctx context.Context, identifier string) (Standard, error) {
+	query := d.DB.Rebind("SELECT " + standardColumns + " FROM standards WHERE identifier=?")
+	standard := Standard{}
+	if err := d.DB.GetContext(ctx, &standard, query, identifier); err != nil {
+		glog.Errorf("Error retrieving standard %s: %+v", identifier, err)
+		return Standard{}, err
+	}
+	return standard, nil
+}
+
+// ListAlignments lists the standards the resource with the given ID is aligned to.
+func (d DBCatalog) ListAlignments(ctx context.Context, resourceID int) ([]Standard, error) {
+	query := "SELECT " + standardColumns + " FROM standards INNER JOIN alignments ON alignments.standard_id=standards.ID WHERE alignments.resource_id=" + strconv.Itoa(resourceID)
+	standards := []Standard{}
+	if err := d.DB.SelectContext(ctx, &standards, query); err != nil {
+		glog.Errorf("Error retrieving alignments for %d: %+v", resourceID, err)
+		return nil, err
+	}
+	return standards, nil
+}
+
+// Search finds resources matching the descriptive text, grades and standards
+// of q, ordered by ID.  Other criteria, which need data the database does not
+// keep alongside resources, fail with ErrUnsupportedQuery.
+func (d DBCatalog) Search(ctx context.Context, q SearchQuery) (ResourceList, error) {
+	where, args, err := dbSearchConditions(q)
+	if err != nil {
+		return ResourceList{}, err
+	}
+	limit := q.Limit
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	var count int
+	countQuery := d.DB.Rebind("SELECT COUNT(*) FROM resources" + where)
+	if err = d.DB.GetContext(ctx, &count, countQuery, args...); err != nil {
+		glog.Errorf("Error counting resources: %+v", err)
+		return ResourceList{}, err
+	}
+	query := d.DB.Rebind(fmt.Sprintf("SELECT %s FROM resources%s ORDER BY ID LIMIT %d OFFSET %d", resourceColumns, where, limit, q.Offset))
+	glog.V(3).Infof("Querying with: %s %v", query, args)
+	rows := []Resource{}
+	if err = d.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		glog.Errorf("Error searching resources: %+v", err)
+		return ResourceList{}, err
+	}
+	list := ResourceList{Resources: make([]WsResource, len(rows))}
+	for i, r := range rows {
+		list.Resources[i] = r.ToWsResource()
+	}
+	list.Meta.Pagination = Pagination{Count: count, Limit: limit, Offset: q.Offset}
+	return list, nil
+}
+
+// dbSearchConditions returns the WHERE clause, with ? placeholders, and its
+// arguments for q.
+func dbSearchConditions(q SearchQuery) (string, []interface{}, error) {
+	if err := q.Validate(); err != nil {
+		return "", nil, err
+	}
+	switch {
+	case len(q.ResourceTypes) > 0:
+		return "", nil, fmt.Errorf("%w: resource types", ErrUnsupportedQuery)
+	case q.Subject != "":
+		return "", nil, fmt.Errorf("%w: subject", ErrUnsupportedQuery)
+	case q.Publisher != "":
+		return "", nil, fmt.Errorf("%w: publisher", ErrUnsupportedQuery)
+	case q.Sort != "":
+		return "", nil, fmt.Errorf("%w: sort", ErrUnsupportedQuery)
+	}
+	var conds []string
+	var args []interface{}
+	if q.Descriptive != "" {
+		conds = append(conds, "(title ILIKE ? OR description ILIKE ?)")
+		like := "%" + q.Descriptive + "%"
+		args = append(args, like, like)
+	}
+	if q.MinGrade != "" || q.MaxGrade != "" {
+		r := strings.SplitN(q.gradesRange(), "-", 2)
+		min, _ := gradeNumber(r[0])
+		max, _ := gradeNumber(r[1])
+		conds = append(conds, "min_grade<=? AND max_grade>=?")
+		args = append(args, max, min)
+	}
+	if len(q.StandardIDs) > 0 {
+		conds = append(conds, "ID IN (SELECT resource_id FROM alignments WHERE standard_id IN ("+placeholders(len(q.StandardIDs))+"))")
+		for _, id := range q.StandardIDs {
+			args = append(args, id)
+		}
+	}
+	if len(q.Standards) > 0 {
+		conds = append(conds, "ID IN (SELECT alignments.resource_id FROM alignments INNER JOIN standards ON standards.ID=alignments.standard_id WHERE standards.identifier IN ("+placeholders(len(q.Standards))+"))")
+		for _, s := range q.Standards {
+			args = append(args, s)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// A FallbackCatalog reads from Primary and, when that fails, from Secondary,
+// e.g. from the partner API when the database is unreachable.
+type FallbackCatalog struct {
+	Primary   Catalog
+	Secondary Catalog
+}
+
+// fallback reports whether a call failing with err should be retried on the
+// secondary catalog.
+func fallback(ctx context.Context, what string, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	glog.V(1).Infof("Primary catalog failed %s, trying secondary: %+v", what, err)
+	return true
+}
+
+// GetResource gets the resource from Primary or else Secondary.
+func (f FallbackCatalog) GetResource(ctx context.Context, ID int) (WsResource, error) {
+	r, err := f.Primary.GetResource(ctx, ID)
+	if fallback(ctx, "getting resource "+strconv.Itoa(ID), err) {
+		return f.Secondary.GetResource(ctx, ID)
+	}
+	return r, err
+}
+
+// GetStandard gets the standard from Primary or else Secondary.
+func (f FallbackCatalog) GetStandard(ctx context.Context, identifier string) (Standard, error) {
+	s, err := f.Primary.GetStandard(ctx, identifier)
+	if fallback(ctx, "getting standard "+identifier, err) {
+		return f.Secondary.GetStandard(ctx, identifier)
+	}
+	return s, err
+}
+
+// ListAlignments lists the alignments from Primary or else Secondary.
+func (f FallbackCatalog) ListAlignments(ctx context.Context, resourceID int) ([]Standard, error) {
+	standards, err := f.Primary.ListAlignments(ctx, resourceID)
+	if fallback(ctx, "listing alignments of "+strconv.Itoa(resourceID), err) {
+		return f.Secondary.ListAlignments(ctx, resourceID)
+	}
+	return standards, err
+}
+
+// Search searches Primary or else Secondary.
+func (f FallbackCatalog) Search(ctx context.Context, q SearchQuery) (ResourceList, error) {
+	list, err := f.Primary.Search(ctx, q)
+	if fallback(ctx, "searching", err) {
+		return f.Secondary.Search(ctx, q)
+	}
+	return list, err
+}
@@ -0,0 +1,113 @@
+package opened
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDBSearchConditions(t *testing.T) {
+	where, args, err := dbSearchConditions(SearchQuery{Descriptive: "count", MinGrade: "K", MaxGrade: "2", Standards: []string{"K.CC.1", "K.CC.2"}})
+	if err != nil {
+		t.Fatalf("Error from dbSearchConditions: %+v", err)
+	}
+	want := " WHERE (title ILIKE ? OR description ILIKE ?) AND min_grade<=? AND max_grade>=? AND " +
+		"ID IN (SELECT alignments.resource_id FROM alignments INNER JOIN standards ON standards.ID=alignments.standard_id WHERE standards.identifier IN (?,?))"
+	if where != want {
+		t.Errorf("Got conditions %s", where)
+	}
+	if wantArgs := []interface{}{"%count%", "%count%", 2, 0, "K.CC.1", "K.CC.2"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Got arguments %v", args)
+	}
+	if where, _, err = dbSearchConditions(SearchQuery{}); err != nil || where != "" {
+		t.Errorf("Got conditions %q, error %+v for an empty query", where, err)
+	}
+	if _, _, err = dbSearchConditions(SearchQuery{Subject: "Math"}); !errors.Is(err, ErrUnsupportedQuery) {
+		t.Errorf("Got error %+v searching by subject", err)
+	}
+}
+
+// fakeCatalog answers every call with its resource or its error.
+type fakeCatalog struct {
+	resource WsResource
+	err      error
+	calls    int
+}
+
+func (f *fakeCatalog) GetResource(ctx context.Context, ID int) (WsResource, error) {
+	f.calls++
+	return f.resource, f.err
+}
+
+func (f *fakeCatalog) GetStandard(ctx context.Context, identifier string) (Standard, error) {
+	f.calls++
+	return Standard{Identifier: identifier}, f.err
+}
+
+func (f *fakeCatalog) ListAlignments(ctx context.Context, resourceID int) ([]Standard, error) {
+	f.calls++
+	return f.resource.Standards, f.err
+}
+
+func (f *fakeCatalog) Search(ctx context.Context, q SearchQuery) (ResourceList, error) {
+	f.calls++
+	return ResourceList{Resources: []WsResource{f.resource}}, f.err
+}
+
+func TestFallbackCatalog(t *testing.T) {
+	ctx := context.Background()
+	db := &fakeCatalog{err: sql.ErrConnDone}
+	api := &fakeCatalog{resource: WsResource{ID: 7, Standards: []Standard{{Identifier: "K.CC.1"}}}}
+	var c Catalog = FallbackCatalog{Primary: db, Secondary: api}
+	if r, err := c.GetResource(ctx, 7); err != nil || r.ID != 7 {
+		t.Errorf("Got resource %+v, error %+v", r, err)
+	}
+	if s, err := c.ListAlignments(ctx, 7); err != nil || len(s) != 1 {
+		t.Errorf("Got alignments %+v, error %+v", s, err)
+	}
+	if db.calls != 2 || api.calls != 2 {
+		t.Errorf("%d primary and %d secondary calls", db.calls, api.calls)
+	}
+
+	db.err = nil
+	if _, err := c.Search(ctx, SearchQuery{}); err != nil || db.calls != 3 || api.calls != 2 {
+		t.Errorf("Secondary used after primary succeeded: error %+v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	db.err = ctx.Err()
+	if _, err := c.GetStandard(ctx, "K.CC.1"); err == nil || api.calls != 2 {
+		t.Errorf("Secondary used after the context was canceled: error %+v", err)
+	}
+}
+
+// TestClientCatalog checks the partner API catalog reads alignments from the resource
+func TestClientCatalog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1/resources/7.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"resource":{"id":7,"standards":[{"id":1000,"identifier":"K.CC.1"}]}}`))
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &NoRetry
+
+	standards, err := c.ListAlignments(context.Background(), 7)
+	if err != nil || len(standards) != 1 || standards[0].Identifier != "K.CC.1" {
+		t.Errorf("Got alignments %+v, error %+v", standards, err)
+	}
+	if _, err = c.ListAlignments(context.Background(), 8); !IsNotFound(err) {
+		t.Errorf("Got error %+v for a missing resource", err)
+	}
+	if !IsNotFound(sql.ErrNoRows) {
+		t.Errorf("Missing database row is not reported as not found")
+	}
+}
@@ -0,0 +1,150 @@
+package opened
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// classesPath is the root of the teacher class endpoints.
+const classesPath = "/1/teachers/classes"
+
+// A Class is a teacher's class of students in OpenEd
+type Class struct {
+	ID            int    `json:"id,omitempty"`
+	Title         string `json:"title"`
+	GradesRange   string `json:"grades_range,omitempty"`
+	StudentsCount int    `json:"students_count,omitempty"`
+}
+
+// A Student is a student rostered in a class
+type Student struct {
+	ID        int    `json:"id,omitempty"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+	Email     string `json:"email,omitempty"`
+	// Password is only sent when creating a student.
+	Password string `json:"password,omitempty"`
+}
+
+// An Assignment is a resource or assessment assigned to a class.
+// Exactly one of ResourceID and AssessmentID is set.
+type Assignment struct {
+	ID           int        `json:"id,omitempty"`
+	ClassID      int        `json:"class_id,omitempty"`
+	ResourceID   int        `json:"resource_id,omitempty"`
+	AssessmentID int        `json:"assessment_id,omitempty"`
+	DueDate      *time.Time `json:"due_date,omitempty"`
+}
+
+func classPath(classID int) string {
+	return classesPath + "/" + strconv.Itoa(classID)
+}
+
+// CreateClass creates a class for the client's teacher and returns it with its ID filled in
+func (c *Client) CreateClass(ctx context.Context, class Class) (Class, error) {
+	var data struct {
+		Class Class `json:"class"`
+	}
+	in := map[string]Class{"class": class}
+	if err := c.do(ctx, "POST", classesPath+".json", nil, in, &data); err != nil {
+		glog.Errorf("Error creating class %s: %+v", class.Title, err)
+		return Class{}, err
+	}
+	glog.V(1).Infof("Created class: %+v", data.Class)
+	return data.Class, nil
+}
+
+// ListClasses lists the classes of the client's teacher
+func (c *Client) ListClasses(ctx context.Context) ([]Class, error) {
+	var data struct {
+		Classes []Class `json:"classes"`
+	}
+	if err := c.get(ctx, classesPath+".json", nil, &data); err != nil {
+		glog.Errorf("Error listing classes: %+v", err)
+		return nil, err
+	}
+	return data.Classes, nil
+}
+
+// UpdateClass saves changes to an existing class
+func (c *Client) UpdateClass(ctx context.Context, class Class) (Class, error) {
+	var data struct {
+		Class Class `json:"class"`
+	}
+	in := map[string]Class{"class": class}
+	if err := c.do(ctx, "PUT", classPath(class.ID)+".json", nil, in, &data); err != nil {
+		glog.Errorf("Error updating class %d: %+v", class.ID, err)
+		return Class{}, err
+	}
+	return data.Class, nil
+}
+
+// ListStudents lists the students in a class
+func (c *Client) ListStudents(ctx context.Context, classID int) ([]Student, error) {
+	var data struct {
+		Students []Student `json:"students"`
+	}
+	if err := c.get(ctx, classPath(classID)+"/students.json", nil, &data); err != nil {
+		glog.Errorf("Error listing students of class %d: %+v", classID, err)
+		return nil, err
+	}
+	return data.Students, nil
+}
+
+// AddStudent rosters a student in a class, creating the student if it has no ID
+func (c *Client) AddStudent(ctx context.Context, classID int, student Student) (Student, error) {
+	var data struct {
+		Student Student `json:"student"`
+	}
+	in := map[string]Student{"student": student}
+	if err := c.do(ctx, "POST", classPath(classID)+"/students.json", nil, in, &data); err != nil {
+		glog.Errorf("Error adding student %s to class %d: %+v", student.Username, classID, err)
+		return Student{}, err
+	}
+	glog.V(1).Infof("Added student to class %d: %+v", classID, data.Student)
+	return data.Student, nil
+}
+
+// RemoveStudent takes a student out of a class
+func (c *Client) RemoveStudent(ctx context.Context, classID int, studentID int) error {
+	err := c.do(ctx, "DELETE", classPath(classID)+"/students/"+strconv.Itoa(studentID)+".json", nil, nil, nil)
+	if err != nil {
+		glog.Errorf("Error removing student %d from class %d: %+v", studentID, classID, err)
+	}
+	return err
+}
+
+// Assign assigns a resource or an assessment to a class
+func (c *Client) Assign(ctx context.Context, classID int, assignment Assignment) (Assignment, error) {
+	if (assignment.ResourceID == 0) == (assignment.AssessmentID == 0) {
+		return Assignment{}, errors.New("opened: an assignment needs exactly one of ResourceID and AssessmentID")
+	}
+	var data struct {
+		Assignment Assignment `json:"assignment"`
+	}
+	assignment.ClassID = classID
+	in := map[string]Assignment{"assignment": assignment}
+	if err := c.do(ctx, "POST", classPath(classID)+"/assignments.json", nil, in, &data); err != nil {
+		glog.Errorf("Error assigning to class %d: %+v", classID, err)
+		return Assignment{}, err
+	}
+	glog.V(1).Infof("Assigned to class %d: %+v", classID, data.Assignment)
+	return data.Assignment, nil
+}
+
+// ListAssignments lists the assignments of a class
+func (c *Client) ListAssignments(ctx context.Context, classID int) ([]Assignment, error) {
+	var data struct {
+		Assignments []Assignment `json:"assignments"`
+	}
+	if err := c.get(ctx, classPath(classID)+"/assignments.json", nil, &data); err != nil {
+		glog.Errorf("Error listing assignments of class %d: %+v", classID, err)
+		return nil, err
+	}
+	return data.Assignments, nil
+}
@@ -0,0 +1,61 @@
+package opened
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClasses provisions a class, rosters a student and assigns a resource
+func TestClasses(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]map[string]interface{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&in)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /1/teachers/classes.json":
+			if in["class"]["title"] != "Period 1" {
+				t.Errorf("Class sent as %+v", in)
+			}
+			w.Write([]byte(`{"class":{"id":5,"title":"Period 1"}}`))
+		case "POST /1/teachers/classes/5/students.json":
+			w.Write([]byte(`{"student":{"id":9,"username":"jdoe"}}`))
+		case "DELETE /1/teachers/classes/5/students/9.json":
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /1/teachers/classes/5/assignments.json":
+			if in["assignment"]["resource_id"] != 7.0 || in["assignment"]["class_id"] != 5.0 {
+				t.Errorf("Assignment sent as %+v", in)
+			}
+			w.Write([]byte(`{"assignment":{"id":11,"class_id":5,"resource_id":7}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	ctx := context.Background()
+
+	class, err := c.CreateClass(ctx, Class{Title: "Period 1"})
+	if err != nil || class.ID != 5 {
+		t.Fatalf("Got class %+v, error %+v", class, err)
+	}
+	student, err := c.AddStudent(ctx, class.ID, Student{Username: "jdoe", FirstName: "J", LastName: "Doe"})
+	if err != nil || student.ID != 9 {
+		t.Fatalf("Got student %+v, error %+v", student, err)
+	}
+	if err = c.RemoveStudent(ctx, class.ID, student.ID); err != nil {
+		t.Errorf("Error from RemoveStudent: %+v", err)
+	}
+	a, err := c.Assign(ctx, class.ID, Assignment{ResourceID: 7})
+	if err != nil || a.ID != 11 {
+		t.Errorf("Got assignment %+v, error %+v", a, err)
+	}
+	if _, err = c.Assign(ctx, class.ID, Assignment{ResourceID: 7, AssessmentID: 8}); err == nil {
+		t.Errorf("Assignment of both a resource and an assessment accepted")
+	}
+}
@@ -0,0 +1,351 @@
+package opened
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// tokenPath is where the partner API hands out OAuth tokens.
+const tokenPath = "/1/oauth/get_token"
+
+// A Client talks to the OpenEd partner web services.  Each Client carries its
+// own base URL and credentials so one process can talk to several partners
+// (e.g. staging and production) side by side.
+type Client struct {
+	// BaseURL is the partner API root such as https://partner.opened.com
+	BaseURL string
+	// HTTPClient is used for every request.  If nil http.DefaultClient is used.
+	HTTPClient *http.Client
+	ClientID   string
+	Secret     string
+	Username   string
+	// Header holds headers sent with every request.
+	Header http.Header
+	// TokenSource supplies the Bearer token for each request.  If nil the
+	// client gets tokens for its own credentials, caches them and refreshes
+	// them before they expire.
+	TokenSource TokenSource
+	// RetryPolicy says how transient failures are retried.  If nil
+	// DefaultRetryPolicy is used.  WithRetryPolicy overrides it for one call.
+	RetryPolicy *RetryPolicy
+	// Cache, if set, answers repeated catalog requests from stored responses.
+	Cache *Cache
+	// Middleware wraps the transport of every partner request, including
+	// token requests.  The first middleware is the outermost.
+	Middleware []Middleware
+	// RateLimits, if set, hold requests back to stay within the partner's quota.
+	RateLimits *RateLimits
+	// Breaker, if set, fails requests at once while the partner keeps failing.
+	Breaker *CircuitBreaker
+
+	httpOnce  sync.Once
+	http      *http.Client
+	tokenOnce sync.Once
+	tokens    TokenSource
+}
+
+// NewClient returns a Client for the partner API at baseURL using the supplied credentials.
+func NewClient(baseURL string, clientID string, secret string, username string) *Client {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{},
+		ClientID:   clientID,
+		Secret:     secret,
+		Username:   username,
+		Header:     h,
+	}
+}
+
+// NewClientFromEnv returns a Client configured from the PARTNER_BASE_URI, CLIENT_ID,
+// CLIENT_SECRET and USERNAME environment variables.
+func NewClientFromEnv() *Client {
+	return NewClient(os.Getenv("PARTNER_BASE_URI"), os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"), os.Getenv("USERNAME"))
+}
+
+// httpClient returns HTTPClient with the client's Middleware, Cache, Breaker
+// and RateLimits in front of its transport.
+func (c *Client) httpClient() *http.Client {
+	c.httpOnce.Do(func() {
+		base := c.HTTPClient
+		if base == nil {
+			base = http.DefaultClient
+		}
+		if c.Cache == nil && len(c.Middleware) == 0 && c.Breaker == nil && c.RateLimits == nil {
+			c.http = base
+			return
+		}
+		transport := base.Transport
+		if c.RateLimits != nil {
+			root := ""
+			if u, err := url.Parse(c.BaseURL); err == nil {
+				root = u.Path
+			}
+			transport = c.RateLimits.transport(root, transport)
+		}
+		if c.Breaker != nil {
+			transport = c.Breaker.Transport(transport)
+		}
+		if c.Cache != nil {
+			transport = c.Cache.Transport(transport)
+		}
+		hc := *base
+		hc.Transport = Chain(transport, c.Middleware...)
+		c.http = &hc
+	})
+	return c.http
+}
+
+func (c *Client) tokenSource() TokenSource {
+	c.tokenOnce.Do(func() {
+		if c.TokenSource != nil {
+			c.tokens = c.TokenSource
+		} else {
+			c.tokens = newPartnerTokenSource(c, c.BaseURL+tokenPath)
+		}
+	})
+	return c.tokens
+}
+
+// Token returns the token used for the client's requests, fetching or
+// refreshing it if needed.
+func (c *Client) Token(ctx context.Context) (*Token, error) {
+	return c.tokenSource().Token(ctx)
+}
+
+// GetToken returns the access token used for the client's requests.
+func (c *Client) GetToken(ctx context.Context) (string, error) {
+	tok, err := c.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
+
+// get issues a GET for path with params and decodes the JSON response into result.
+func (c *Client) get(ctx context.Context, path string, params url.Values, result interface{}) error {
+	return c.do(ctx, "GET", path, params, nil, result)
+}
+
+// do sends a request for path with params and, if in is not nil, in encoded as
+// a JSON body.  A JSON response is decoded into result if it is not nil.
+// A non-2xx response is returned as an *APIError.
+func (c *Client) do(ctx context.Context, method string, path string, params url.Values, in interface{}, result interface{}) error {
+	_, body, err := c.roundTrip(ctx, method, path, params, in, false)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, result)
+}
+
+// stream issues a GET for path with params like get but returns the body of
+// the response unread, so it can be decoded as it arrives.  Close it when done.
+func (c *Client) stream(ctx context.Context, path string, params url.Values) (io.ReadCloser, error) {
+	resp, _, err := c.roundTrip(ctx, "GET", path, params, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// roundTrip sends a request for path with params and, if in is not nil, in
+// encoded as a JSON body, returning the response and its body.  If stream is
+// set the body of a successful response is left unread in resp.Body instead.
+// Transient failures are retried following the client's RetryPolicy, and a
+// request rejected with 401 is retried once with a fresh token.
+// A non-2xx response is returned as an *APIError.
+func (c *Client) roundTrip(ctx context.Context, method string, path string, params url.Values, in interface{}, stream bool) (*http.Response, []byte, error) {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return nil, nil, err
+		}
+	}
+	ts := c.tokenSource()
+	tok, err := ts.Token(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, body, attempts, err := c.sendRetry(ctx, method, path, params, payload, tok.AccessToken, stream)
+	if err != nil {
+		return nil, nil, err
+	}
+	if inv, ok := ts.(invalidator); ok && resp.StatusCode == http.StatusUnauthorized {
+		glog.V(1).Infof("Token rejected for %s, retrying with a fresh token", path)
+		inv.Invalidate(tok.AccessToken)
+		if tok, err = ts.Token(ctx); err != nil {
+			return nil, nil, err
+		}
+		var n int
+		resp, body, n, err = c.sendRetry(ctx, method, path, params, payload, tok.AccessToken, stream)
+		attempts += n
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newAPIError(path, resp, body)
+		apiErr.Attempts = attempts
+		return nil, nil, apiErr
+	}
+	return resp, body, nil
+}
+
+// sendRetry calls send until it succeeds, fails permanently or runs out of
+// the attempts allowed by the retry policy.  It returns the number of attempts made.
+func (c *Client) sendRetry(ctx context.Context, method string, path string, params url.Values, payload []byte, accessToken string, stream bool) (*http.Response, []byte, int, error) {
+	p := c.retryPolicy(ctx)
+	max := p.attempts(method)
+	for attempt := 1; ; attempt++ {
+		resp, body, err := c.send(ctx, method, path, params, payload, accessToken, stream)
+		wait := p.backoff(attempt)
+		switch {
+		case err != nil && (ctx.Err() != nil || errors.Is(err, ErrBreakerOpen)):
+			return nil, nil, attempt, err
+		case err != nil:
+			if attempt >= max {
+				return nil, nil, attempt, fmt.Errorf("opened: %s failed after %d attempts: %w", path, attempt, err)
+			}
+		case retryableStatus(resp.StatusCode):
+			if attempt >= max {
+				return resp, body, attempt, nil
+			}
+			if d, ok := retryAfter(resp); ok {
+				if p.MaxBackoff > 0 && d > p.MaxBackoff {
+					glog.V(1).Infof("Not retrying %s: partner asked for a wait of %s", path, d)
+					return resp, body, attempt, nil
+				}
+				wait = d
+			}
+		default:
+			return resp, body, attempt, nil
+		}
+		glog.V(1).Infof("Retrying %s in %s after attempt %d of %d", path, wait, attempt, max)
+		if err = sleep(ctx, wait); err != nil {
+			return nil, nil, attempt, err
+		}
+	}
+}
+
+// send issues a single request for path and returns the response with its
+// body read, unless stream is set and the request succeeded.
+func (c *Client) send(ctx context.Context, method string, path string, params url.Values, payload []byte, accessToken string, stream bool) (*http.Response, []byte, error) {
+	uri := c.BaseURL + path
+	if len(params) > 0 {
+		uri = uri + "?" + params.Encode()
+	}
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, uri, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	glog.V(2).Infof("Hitting URI %s %s", method, uri)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stream && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		glog.V(2).Infof("Response %s, streaming body", resp.Status)
+		return resp, nil, nil
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	glog.V(2).Infof("Response %s, %d bytes", resp.Status, len(body))
+	return resp, body, nil
+}
+
+// SearchResources searches OpenEd for resources given set of queryParams.
+func (c *Client) SearchResources(ctx context.Context, queryParams map[string]string) (ResourceList, error) {
+	p := url.Values{}
+	for k, v := range queryParams {
+		p.Set(k, v)
+	}
+	glog.V(2).Infof("Query parameters %+v", p)
+	resources := ResourceList{}
+	if err := c.get(ctx, "/1/resources.json", p, &resources); err != nil {
+		glog.Errorf("Error searching resources: %+v", err)
+		return ResourceList{}, err
+	}
+	return resources, nil
+}
+
+// GetResource fetches the resource with the given ID from the partner API.
+// Unlike Resource.GetResource it needs no database access.
+func (c *Client) GetResource(ctx context.Context, ID int) (WsResource, error) {
+	var data struct {
+		Resource WsResource `json:"resource"`
+	}
+	if err := c.get(ctx, "/1/resources/"+strconv.Itoa(ID)+".json", nil, &data); err != nil {
+		glog.Errorf("Error retrieving resource %d: %+v", ID, err)
+		return WsResource{}, err
+	}
+	glog.V(1).Infof("Resource is: %+v", data.Resource)
+	return data.Resource, nil
+}
+
+// GetResources fetches each of the resources with the given IDs, in order.
+// It stops at the first failure, returning the resources fetched so far.
+func (c *Client) GetResources(ctx context.Context, IDs []int) ([]WsResource, error) {
+	resources := make([]WsResource, 0, len(IDs))
+	for _, ID := range IDs {
+		r, err := c.GetResource(ctx, ID)
+		if err != nil {
+			return resources, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+// ListStandardGroups lists all of the standard groups
+func (c *Client) ListStandardGroups(ctx context.Context) (StandardGroupList, error) {
+	groups := StandardGroupList{}
+	if err := c.get(ctx, "/1/standard_groups.json", nil, &groups); err != nil {
+		glog.Errorf("Error listing groups: %+v", err)
+		return StandardGroupList{}, err
+	}
+	glog.V(2).Infof("Groups: %+v", groups)
+	return groups, nil
+}
+
+// ListGradeGroups lists all of the grade groups for the standard group with the given ID
+func (c *Client) ListGradeGroups(ctx context.Context, ID int) (GradeGroupList, error) {
+	p := url.Values{}
+	p.Set("standard_group", strconv.Itoa(ID))
+	glog.V(2).Infof("Query parameters %+v", p)
+	groups := GradeGroupList{}
+	if err := c.get(ctx, "/1/grade_groups.json", p, &groups); err != nil {
+		glog.Errorf("Error listing groups: %+v", err)
+		return GradeGroupList{}, err
+	}
+	glog.V(2).Infof("Groups: %+v", groups)
+	return groups, nil
+}
@@ -0,0 +1,88 @@
+package opened
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestClientsSideBySide checks that two clients keep their own base URL and credentials
+func TestClientsSideBySide(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case tokenPath:
+				w.Write([]byte(`{"access_token":"` + name + `-` + r.FormValue("client_id") + `"}`))
+			case "/1/standard_groups.json":
+				if r.Header.Get("Authorization") != "Bearer "+name+"-id" {
+					t.Errorf("%s got Authorization %q", name, r.Header.Get("Authorization"))
+				}
+				w.Write([]byte(`{"standard_groups":[{"id":1,"title":"` + name + `"}]}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}
+	}
+	staging := httptest.NewServer(handler("staging"))
+	defer staging.Close()
+	production := httptest.NewServer(handler("production"))
+	defer production.Close()
+
+	for name, uri := range map[string]string{"staging": staging.URL, "production": production.URL} {
+		c := NewClient(uri+"/", "id", "secret", "user")
+		groups, err := c.ListStandardGroups(context.Background())
+		if err != nil {
+			t.Fatalf("Error from ListStandardGroups: %+v", err)
+		}
+		if len(groups.StandardGroups) != 1 || groups.StandardGroups[0].Title != name {
+			t.Errorf("%s client got groups %+v", name, groups)
+		}
+	}
+}
+
+// TestClientContext checks that a deadline on the context cuts a slow request short
+func TestClientContext(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.SearchResources(ctx, map[string]string{"descriptive": "counting"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %+v", err)
+	}
+}
+
+func TestGetResource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1/resources/7.json":
+			w.Write([]byte(`{"resource":{"id":7,"title":"Counting to 10","youtube_id":"abc"}}`))
+		case "/1/resources/8.json":
+			w.Write([]byte(`{"resource":{"id":8,"title":"Counting to 20"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+
+	r, err := c.GetResource(context.Background(), 7)
+	if err != nil || r.ID != 7 || r.Title != "Counting to 10" || r.YoutubeID != "abc" {
+		t.Errorf("Got resource %+v, error %+v", r, err)
+	}
+	resources, err := c.GetResources(context.Background(), []int{8, 7, 9})
+	if !IsNotFound(err) || len(resources) != 2 || resources[0].ID != 8 {
+		t.Errorf("Got resources %+v, error %+v", resources, err)
+	}
+}
@@ -0,0 +1,205 @@
+package opened
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// A ResourceView is the read-only view of a resource shared by Resource, from
+// the database, and WsResource, from the partner API, so code can handle
+// either alike.  NULL columns read as zero values.
+type ResourceView interface {
+	GetID() int
+	GetTitle() string
+	GetURL() string
+	GetPublisherID() int
+	GetResourceTypeID() int
+	GetYoutubeID() string
+}
+
+// GetID returns the resource's ID.
+func (resource Resource) GetID() int { return resource.ID }
+
+// GetTitle returns the resource's title.
+func (resource Resource) GetTitle() string { return resource.Title.String }
+
+// GetURL returns the resource's share URL.
+func (resource Resource) GetURL() string { return resource.URL.String }
+
+// GetPublisherID returns the ID of the resource's publisher.
+func (resource Resource) GetPublisherID() int { return int(resource.PublisherID.Int64) }
+
+// GetResourceTypeID returns the ID of the resource's type.
+func (resource Resource) GetResourceTypeID() int { return int(resource.ResourceTypeID.Int64) }
+
+// GetYoutubeID returns the resource's YouTube video ID.
+func (resource Resource) GetYoutubeID() string { return resource.YoutubeID.String }
+
+// GetID returns the resource's ID.
+func (r WsResource) GetID() int { return r.ID }
+
+// GetTitle returns the resource's title.
+func (r WsResource) GetTitle() string { return r.Title }
+
+// GetURL returns the resource's share URL.
+func (r WsResource) GetURL() string { return r.URL }
+
+// GetPublisherID returns the ID of the resource's publisher.
+func (r WsResource) GetPublisherID() int { return r.PublisherID }
+
+// GetResourceTypeID returns the ID of the resource's type.
+func (r WsResource) GetResourceTypeID() int { return r.ResourceTypeID }
+
+// GetYoutubeID returns the resource's YouTube video ID.
+func (r WsResource) GetYoutubeID() string { return r.YoutubeID }
+
+// dbFields holds what a WsResource converted from a Resource keeps of the
+// resource that the partner API has no field for, so ToResource can give it
+// back.  It is never encoded.
+type dbFields struct {
+	usageCount    sql.NullInt64
+	effectiveness string
+	// emptyColumns are the JSON names of the columns which are empty
+	// rather than NULL.
+	emptyColumns map[string]bool
+}
+
+// resourceColumnFields are the lower-cased JSON names of the WsResource fields
+// kept in resources columns.
+var resourceColumnFields = map[string]bool{
+	"id": true, "title": true, "url": true, "publisher_id": true, "contribution_id": true,
+	"description": true, "resource_type_id": true, "youtube_id": true, "subjects": true,
+}
+
+// ToWsResource converts a database resource to its partner API form.  NULL
+// columns become zero values and Subject is split at ", " into Subjects.  The
+// fields in the resource's Extra are restored, and UsageCount, Effectiveness
+// and which columns are empty rather than NULL are kept out of the encoded
+// resource, so ToResource gives back the same Resource.
+func (resource Resource) ToWsResource() WsResource {
+	r := WsResource{}
+	if len(resource.Extra) > 0 {
+		if data, err := json.Marshal(resource.Extra); err == nil {
+			json.Unmarshal(data, &r)
+		}
+	}
+	r.ID = resource.ID
+	r.Title = resource.Title.String
+	r.URL = resource.URL.String
+	r.PublisherID = int(resource.PublisherID.Int64)
+	r.ContributionID = int(resource.ContributionID.Int64)
+	r.Description = resource.Description.String
+	r.ResourceTypeID = int(resource.ResourceTypeID.Int64)
+	r.YoutubeID = resource.YoutubeID.String
+	if strings.Join(r.Subjects, ", ") != resource.Subject {
+		r.Subjects = splitSubjects(resource.Subject)
+	}
+	db := &dbFields{usageCount: resource.UsageCount, effectiveness: resource.Effectiveness}
+	for _, col := range []struct {
+		name  string
+		empty bool
+	}{
+		{"title", resource.Title.Valid && resource.Title.String == ""},
+		{"url", resource.URL.Valid && resource.URL.String == ""},
+		{"publisher_id", resource.PublisherID.Valid && resource.PublisherID.Int64 == 0},
+		{"contribution_id", resource.ContributionID.Valid && resource.ContributionID.Int64 == 0},
+		{"description", resource.Description.Valid && resource.Description.String == ""},
+		{"resource_type_id", resource.ResourceTypeID.Valid && resource.ResourceTypeID.Int64 == 0},
+		{"youtube_id", resource.YoutubeID.Valid && resource.YoutubeID.String == ""},
+	} {
+		if col.empty {
+			if db.emptyColumns == nil {
+				db.emptyColumns = map[string]bool{}
+			}
+			db.emptyColumns[col.name] = true
+		}
+	}
+	if db.usageCount.Valid || db.effectiveness != "" || db.emptyColumns != nil {
+		r.db = db
+	}
+	return r
+}
+
+// ToResource converts a partner API resource to its database form.  Zero
+// values become NULL columns, unless they were empty columns of the Resource
+// r was converted from, and Subjects are joined with ", ".  Fields with no
+// database column, such as Standards and Rating, are kept in the resource's
+// Extra, so ToWsResource gives back the same WsResource.
+func (r WsResource) ToResource() Resource {
+	db := r.db
+	if db == nil {
+		db = &dbFields{}
+	}
+	empty := db.emptyColumns
+	return Resource{
+		ID:             r.ID,
+		Title:          nullString(r.Title, empty["title"]),
+		URL:            nullString(r.URL, empty["url"]),
+		PublisherID:    nullInt64(r.PublisherID, empty["publisher_id"]),
+		ContributionID: nullInt64(r.ContributionID, empty["contribution_id"]),
+		Description:    nullString(r.Description, empty["description"]),
+		ResourceTypeID: nullInt64(r.ResourceTypeID, empty["resource_type_id"]),
+		YoutubeID:      nullString(r.YoutubeID, empty["youtube_id"]),
+		Subject:        strings.Join(r.Subjects, ", "),
+		UsageCount:     db.usageCount,
+		Effectiveness:  db.effectiveness,
+		Extra:          r.extraFields(),
+	}
+}
+
+// extraFields returns the set fields of r with no resources column, and any
+// subjects splitting Subject would not give back, keyed by their JSON names.
+func (r WsResource) extraFields() map[string]json.RawMessage {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	var extra map[string]json.RawMessage
+	for k, v := range fields {
+		switch {
+		case k == "subjects":
+			if reflect.DeepEqual(splitSubjects(strings.Join(r.Subjects, ", ")), r.Subjects) {
+				continue
+			}
+		case resourceColumnFields[strings.ToLower(k)]:
+			continue
+		case wsResourceFields[strings.ToLower(k)] && isZeroJSON(v):
+			continue
+		}
+		if extra == nil {
+			extra = map[string]json.RawMessage{}
+		}
+		extra[k] = v
+	}
+	return extra
+}
+
+// isZeroJSON reports whether v is the encoding of a zero value.
+func isZeroJSON(v json.RawMessage) bool {
+	switch string(v) {
+	case "null", "0", `""`, "false":
+		return true
+	}
+	return false
+}
+
+func splitSubjects(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ", ")
+}
+
+func nullString(s string, empty bool) sql.NullString {
+	return sql.NullString{String: s, Valid: s != "" || empty}
+}
+
+func nullInt64(n int, empty bool) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0 || empty}
+}
@@ -0,0 +1,105 @@
+package opened
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_ ResourceView = Resource{}
+	_ ResourceView = WsResource{}
+)
+
+func TestResourceConversion(t *testing.T) {
+	standards, _ := json.Marshal([]Standard{{ID: 1000, Identifier: "K.CC.1"}})
+	for _, resource := range []Resource{
+		{
+			ID:             7,
+			Title:          sql.NullString{String: "Counting to 10", Valid: true},
+			URL:            sql.NullString{String: "https://example.com/7", Valid: true},
+			PublisherID:    sql.NullInt64{Int64: 3, Valid: true},
+			ContributionID: sql.NullInt64{Int64: 4, Valid: true},
+			Description:    sql.NullString{String: "Count along", Valid: true},
+			ResourceTypeID: sql.NullInt64{Int64: 1, Valid: true},
+			YoutubeID:      sql.NullString{String: "abc", Valid: true},
+			UsageCount:     sql.NullInt64{Int64: 0, Valid: true},
+			Effectiveness:  "0.8",
+			Subject:        "Math",
+			Extra:          map[string]json.RawMessage{"rating": json.RawMessage(`4.5`), "standards": standards},
+		},
+		{ID: 8},
+		{
+			ID:          9,
+			Title:       sql.NullString{String: "", Valid: true},
+			PublisherID: sql.NullInt64{Int64: 0, Valid: true},
+			Subject:     "Math,Art",
+		},
+	} {
+		ws := resource.ToWsResource()
+		if got := ws.ToResource(); !reflect.DeepEqual(got, resource) {
+			t.Errorf("Resource %+v came back as %+v", resource, got)
+		}
+		// database resources encode like partner API ones
+		data, err := json.Marshal(ws)
+		var fields map[string]json.RawMessage
+		if err == nil {
+			err = json.Unmarshal(data, &fields)
+		}
+		for k := range fields {
+			if !wsResourceFields[strings.ToLower(k)] {
+				t.Errorf("Resource %d encodes unmapped field %s", resource.ID, k)
+			}
+		}
+		if err != nil {
+			t.Errorf("Error encoding resource %d: %+v", resource.ID, err)
+		}
+		var a, b ResourceView = resource, ws
+		if a.GetID() != b.GetID() || a.GetTitle() != b.GetTitle() || a.GetURL() != b.GetURL() ||
+			a.GetPublisherID() != b.GetPublisherID() || a.GetResourceTypeID() != b.GetResourceTypeID() ||
+			a.GetYoutubeID() != b.GetYoutubeID() {
+			t.Errorf("Views of %+v and %+v differ", resource, ws)
+		}
+	}
+
+	ws := WsResource{ID: 9, Title: "Shapes", Subjects: []string{"Math", "Art"}, Rating: 4.5}
+	resource := ws.ToResource()
+	if resource.Subject != "Math, Art" || resource.PublisherID.Valid || resource.Title.String != "Shapes" {
+		t.Errorf("Got resource %+v", resource)
+	}
+
+	created := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, ws := range []WsResource{
+		ws,
+		{},
+		{
+			ID:             10,
+			Title:          "Counting to 10",
+			URL:            "https://example.com/10",
+			PublisherID:    3,
+			ContributionID: 4,
+			Description:    "Count along",
+			ResourceTypeID: 1,
+			YoutubeID:      "abc",
+			UseRightsURL:   "https://example.com/rights",
+			GradesRange:    "K-2",
+			Standards:      []Standard{{ID: 1000, Identifier: "K.CC.1"}},
+			Thumbnail:      "https://example.com/10.png",
+			Rating:         4.5,
+			RatingsCount:   12,
+			Subjects:       []string{"Math", "Counting, cardinality"},
+			Duration:       90,
+			Premium:        true,
+			CreatedAt:      &created,
+			Extra:          map[string]json.RawMessage{"license": json.RawMessage(`"cc-by"`), "usage_count": json.RawMessage(`0`)},
+		},
+		{ID: 11, Subjects: []string{}, Standards: []Standard{}},
+	} {
+		if got := ws.ToResource().ToWsResource(); !reflect.DeepEqual(got, ws) {
+			t.Errorf("WsResource %+v came back as %+v", ws, got)
+		}
+	}
+}
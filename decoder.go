@@ -0,0 +1,141 @@
+package opened
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A ResourceDecoder reads the resources of a resource list, such as the body
+// of a /1/resources.json response, one at a time as they arrive, so a page of
+// any size is never held in memory at once.  Call Next until it returns false,
+// then check Err:
+//
+//	d := opened.NewResourceDecoder(body)
+//	for d.Next() {
+//		r := d.Resource()
+//		...
+//	}
+//	if err := d.Err(); err != nil {
+//		...
+//	}
+type ResourceDecoder struct {
+	dec     *json.Decoder
+	meta    ListMeta
+	hasMeta bool
+	cur     WsResource
+	started bool
+	inList  bool
+	done    bool
+	err     error
+}
+
+// NewResourceDecoder returns a ResourceDecoder reading from r.
+func NewResourceDecoder(r io.Reader) *ResourceDecoder {
+	return &ResourceDecoder{dec: json.NewDecoder(r)}
+}
+
+// Next decodes the next resource.  It returns false at the end of the list or
+// on an error.
+func (d *ResourceDecoder) Next() bool {
+	if d.done || d.err != nil {
+		return false
+	}
+	if !d.inList && !d.findList() {
+		return false
+	}
+	if d.dec.More() {
+		var r WsResource
+		if d.err = d.dec.Decode(&r); d.err != nil {
+			return false
+		}
+		d.cur = r
+		return true
+	}
+	// read the closing ] and whatever follows the list, such as its meta
+	if _, d.err = d.dec.Token(); d.err != nil {
+		return false
+	}
+	d.inList = false
+	d.findList()
+	return false
+}
+
+// findList reads up to the start of the resources array, decoding the meta
+// block and skipping other fields on the way.  It returns false if the object
+// ends first.
+func (d *ResourceDecoder) findList() bool {
+	if !d.started {
+		d.started = true
+		if d.err = d.expect(json.Delim('{')); d.err != nil {
+			return false
+		}
+	}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			d.err = err
+			return false
+		}
+		key, _ := tok.(string)
+		switch strings.ToLower(key) {
+		case "resources":
+			tok, err = d.dec.Token()
+			if err != nil {
+				d.err = err
+				return false
+			}
+			if tok == nil {
+				continue
+			}
+			if tok != json.Delim('[') {
+				d.err = fmt.Errorf("opened: resources is %v, not a list", tok)
+				return false
+			}
+			d.inList = true
+			return true
+		case "meta":
+			err = d.dec.Decode(&d.meta)
+			d.hasMeta = true
+		default:
+			var skip json.RawMessage
+			err = d.dec.Decode(&skip)
+		}
+		if err != nil {
+			d.err = err
+			return false
+		}
+	}
+	d.err = d.expect(json.Delim('}'))
+	d.done = true
+	return false
+}
+
+// expect reads the next token and checks it is want.
+func (d *ResourceDecoder) expect(want json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("opened: expected %v in resource list, got %v", want, tok)
+	}
+	return nil
+}
+
+// Resource returns the current resource.
+func (d *ResourceDecoder) Resource() WsResource {
+	return d.cur
+}
+
+// Meta returns the meta block of the list.  It is only complete once Next
+// has returned false.
+func (d *ResourceDecoder) Meta() ListMeta {
+	return d.meta
+}
+
+// Err returns the error which stopped the decoding, if any.
+func (d *ResourceDecoder) Err() error {
+	return d.err
+}
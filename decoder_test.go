@@ -0,0 +1,68 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResourceDecoder(t *testing.T) {
+	for _, tc := range []struct {
+		body  string
+		ids   []int
+		count int
+		bad   bool
+	}{
+		{`{"resources":[{"id":1},{"id":2,"title":"x"}],"meta":{"pagination":{"count":9}}}`, []int{1, 2}, 9, false},
+		{`{"meta":{"pagination":{"count":3}},"extra":[1,{"a":2}],"Resources":[{"id":3}]}`, []int{3}, 3, false},
+		{`{"resources":null,"meta":{"pagination":{"count":0}}}`, nil, 0, false},
+		{`{"meta":{}}`, nil, 0, false},
+		{`{"resources":[{"id":1},{"id":`, []int{1}, 0, true},
+		{`{"resources":{"id":1}}`, nil, 0, true},
+		{`[]`, nil, 0, true},
+	} {
+		d := NewResourceDecoder(strings.NewReader(tc.body))
+		var ids []int
+		for d.Next() {
+			ids = append(ids, d.Resource().ID)
+		}
+		if len(ids) != len(tc.ids) || (len(ids) > 0 && ids[0] != tc.ids[0]) {
+			t.Errorf("Decoding %s: got IDs %v", tc.body, ids)
+		}
+		if (d.Err() != nil) != tc.bad {
+			t.Errorf("Decoding %s: got error %+v", tc.body, d.Err())
+		}
+		if !tc.bad && d.Meta().Pagination.Count != tc.count {
+			t.Errorf("Decoding %s: got meta %+v", tc.body, d.Meta())
+		}
+	}
+}
+
+// TestSearchResourcesIterStreams checks resources are handed out before the
+// whole page has arrived
+func TestSearchResourcesIterStreams(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"resources":[{"id":1},`))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte(`{"id":2}],"meta":{"pagination":{"count":2}}}`))
+	}))
+	defer ts.Close()
+	defer close(release)
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+
+	it := c.SearchResourcesIter(context.Background(), nil, 0)
+	if !it.Next() || it.Resource().ID != 1 {
+		t.Fatalf("Got resource %+v, error %+v", it.Resource(), it.Err())
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("Error from Close: %+v", err)
+	}
+	if it.Next() {
+		t.Errorf("Next after Close returned %+v", it.Resource())
+	}
+}
@@ -0,0 +1,80 @@
+package opened
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// An APIError is returned when the partner API answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	// Endpoint is the path that was requested, such as /1/resources.json
+	Endpoint string
+	// RequestID is the partner's X-Request-Id for the request, if any.
+	RequestID string
+	// Message is the error message from the response body, if any.
+	Message string
+	// Body is the decoded JSON error body.  It is nil if the body was not a JSON object.
+	Body map[string]interface{}
+	// Attempts is how many times the request was tried.
+	Attempts int
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("opened: %s returned %d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg = msg + ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg = msg + " (request " + e.RequestID + ")"
+	}
+	if e.Attempts > 1 {
+		msg = fmt.Sprintf("%s after %d attempts", msg, e.Attempts)
+	}
+	return msg
+}
+
+// newAPIError builds an APIError from a failed response and its body.
+func newAPIError(endpoint string, resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	if json.Unmarshal(body, &e.Body) == nil {
+		for _, k := range []string{"error_description", "message", "error"} {
+			if s, ok := e.Body[k].(string); ok && s != "" {
+				e.Message = s
+				break
+			}
+		}
+	} else if len(body) > 0 && len(body) < 512 {
+		e.Message = string(body)
+	}
+	return e
+}
+
+// statusIs reports whether err is an APIError with the given status code.
+func statusIs(err error, code int) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.StatusCode == code
+}
+
+// IsUnauthorized reports whether err is an APIError for a 401 response.
+func IsUnauthorized(err error) bool {
+	return statusIs(err, http.StatusUnauthorized)
+}
+
+// IsNotFound reports whether err is an APIError for a 404 response, or a
+// database lookup which found no rows.
+func IsNotFound(err error) bool {
+	return statusIs(err, http.StatusNotFound) || errors.Is(err, sql.ErrNoRows)
+}
+
+// IsRateLimited reports whether err is an APIError for a 429 response.
+func IsRateLimited(err error) bool {
+	return statusIs(err, http.StatusTooManyRequests)
+}
@@ -0,0 +1,42 @@
+package opened
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		switch r.URL.Path {
+		case "/1/standard_groups.json":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"no such thing"}`))
+		case "/1/grade_groups.json":
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &NoRetry
+
+	_, err := c.ListStandardGroups(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an APIError, got %+v", err)
+	}
+	if !IsNotFound(err) || IsUnauthorized(err) || IsRateLimited(err) {
+		t.Errorf("Wrong status helpers for %+v", err)
+	}
+	if apiErr.Endpoint != "/1/standard_groups.json" || apiErr.RequestID != "req-1" || apiErr.Message != "no such thing" || apiErr.Body["error"] != "not_found" {
+		t.Errorf("Wrong APIError fields: %+v", apiErr)
+	}
+
+	if _, err = c.ListGradeGroups(context.Background(), 1); !IsRateLimited(err) {
+		t.Errorf("Expected rate limited error, got %+v", err)
+	}
+}
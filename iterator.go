@@ -0,0 +1,172 @@
+package opened
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// defaultPageSize is the page size used when walking search results if the
+// query does not ask for one.
+const defaultPageSize = 50
+
+// Pagination describes which page of a list the partner API returned.
+type Pagination struct {
+	// Count is the total number of results across all pages.
+	Count  int `json:"count"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// ListMeta is the meta block of a list response.
+type ListMeta struct {
+	Pagination Pagination `json:"pagination"`
+}
+
+// A ResourceIterator walks every page of a resource search, decoding each
+// page as it arrives.  Call Next until it returns false, then check Err:
+//
+//	it := c.SearchResourcesIter(ctx, params, 1000)
+//	for it.Next() {
+//		r := it.Resource()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Call Close if you stop before Next returns false.
+type ResourceIterator struct {
+	c          *Client
+	ctx        context.Context
+	params     url.Values
+	maxResults int
+	limit      int
+	offset     int
+
+	body     io.ReadCloser
+	page     *ResourceDecoder
+	pageSeen int
+	cur      WsResource
+	seen     int
+	last     bool
+	err      error
+}
+
+// SearchResourcesIter returns a ResourceIterator over all results of the search
+// given by queryParams, fetching further pages of /1/resources.json as needed.
+// Any limit and offset in queryParams set the page size, up to 100, and the
+// starting point.
+// At most maxResults resources are returned; 0 means no cap.
+func (c *Client) SearchResourcesIter(ctx context.Context, queryParams map[string]string, maxResults int) *ResourceIterator {
+	params := url.Values{}
+	for k, v := range queryParams {
+		params.Set(k, v)
+	}
+	return newResourceIterator(ctx, c, params, maxResults)
+}
+
+func newResourceIterator(ctx context.Context, c *Client, params url.Values, maxResults int) *ResourceIterator {
+	it := &ResourceIterator{
+		c:          c,
+		ctx:        ctx,
+		params:     params,
+		maxResults: maxResults,
+		limit:      defaultPageSize,
+	}
+	if n, err := strconv.Atoi(params.Get("limit")); err == nil && n > 0 {
+		it.limit = n
+	}
+	if it.limit > maxPageSize {
+		it.limit = maxPageSize
+	}
+	if n, err := strconv.Atoi(params.Get("offset")); err == nil && n > 0 {
+		it.offset = n
+	}
+	return it
+}
+
+// Next advances to the next resource, fetching the next page if needed.
+// It returns false when the results are exhausted or an error occurred.
+func (it *ResourceIterator) Next() bool {
+	if it.err != nil || (it.maxResults > 0 && it.seen >= it.maxResults) {
+		it.Close()
+		return false
+	}
+	for {
+		if it.page == nil {
+			if it.last {
+				return false
+			}
+			if it.err = it.fetch(); it.err != nil {
+				return false
+			}
+		}
+		if it.page.Next() {
+			it.cur = it.page.Resource()
+			it.pageSeen++
+			it.seen++
+			return true
+		}
+		it.err = it.page.Err()
+		hasMeta, p := it.page.hasMeta, it.page.Meta().Pagination
+		it.closePage()
+		if it.err != nil {
+			return false
+		}
+		it.offset += it.pageSeen
+		// the partner may return shorter pages than asked for, so a short
+		// page only ends the results when there is no meta to go by
+		switch {
+		case it.pageSeen == 0:
+			it.last = true
+		case hasMeta && p.Count > 0:
+			it.last = it.offset >= p.Count
+		case hasMeta && p.Limit > 0:
+			it.last = it.pageSeen < p.Limit
+		default:
+			it.last = it.pageSeen < it.limit
+		}
+	}
+}
+
+// fetch starts reading the next page of results.
+func (it *ResourceIterator) fetch() error {
+	it.params.Set("limit", strconv.Itoa(it.limit))
+	it.params.Set("offset", strconv.Itoa(it.offset))
+	body, err := it.c.stream(it.ctx, "/1/resources.json", it.params)
+	if err != nil {
+		return err
+	}
+	it.body = body
+	it.page = NewResourceDecoder(body)
+	it.pageSeen = 0
+	return nil
+}
+
+// Close stops the iteration and releases the page being read.
+func (it *ResourceIterator) Close() error {
+	it.last = true
+	return it.closePage()
+}
+
+func (it *ResourceIterator) closePage() error {
+	if it.body == nil {
+		return nil
+	}
+	err := it.body.Close()
+	it.body = nil
+	it.page = nil
+	return err
+}
+
+// Resource returns the current resource.
+func (it *ResourceIterator) Resource() WsResource {
+	return it.cur
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ResourceIterator) Err() error {
+	return it.err
+}
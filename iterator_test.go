@@ -0,0 +1,59 @@
+package opened
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// TestSearchResourcesIter walks several pages of a fake search, which returns
+// at most 20 resources a page and leaves out the meta block if asked
+func TestSearchResourcesIter(t *testing.T) {
+	const total, maxLimit = 23, 20
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("descriptive") != "counting" {
+			t.Errorf("Query parameter lost: %s", r.URL.RawQuery)
+		}
+		limit, _ := strconv.Atoi(r.FormValue("limit"))
+		offset, _ := strconv.Atoi(r.FormValue("offset"))
+		if limit > maxPageSize {
+			t.Errorf("Asked for a page of %d", limit)
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		list := ResourceList{Meta: ListMeta{Pagination{Count: total, Limit: limit, Offset: offset}}}
+		for id := offset; id < total && id < offset+limit; id++ {
+			list.Resources = append(list.Resources, WsResource{ID: id})
+		}
+		if r.FormValue("meta") == "none" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"resources": list.Resources})
+			return
+		}
+		json.NewEncoder(w).Encode(list)
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+
+	for _, tc := range []struct {
+		limit, max, want int
+		meta             string
+	}{{10, 0, total, ""}, {5, 12, 12, ""}, {50, 0, total, ""}, {250, 0, total, ""}, {10, 0, total, "none"}} {
+		params := map[string]string{"descriptive": "counting", "limit": strconv.Itoa(tc.limit), "meta": tc.meta}
+		it := c.SearchResourcesIter(context.Background(), params, tc.max)
+		n := 0
+		for it.Next() {
+			if it.Resource().ID != n {
+				t.Errorf("Resource %d has ID %d", n, it.Resource().ID)
+			}
+			n++
+		}
+		if it.Err() != nil || n != tc.want {
+			t.Errorf("Page size %d, max %d, meta %q: got %d resources, error %+v", tc.limit, tc.max, tc.meta, n, it.Err())
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package opened
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// A Middleware wraps the RoundTripper which sends partner requests, e.g. to
+// sign, log, measure or fail them.  Set them as Client.Middleware.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc lets an ordinary function be used as an http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps base, or http.DefaultTransport if base is nil, in middleware.
+// The first middleware is the outermost and so sees each request first.
+func Chain(base http.RoundTripper, middleware ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		base = middleware[i](base)
+	}
+	return base
+}
+
+// redactedHeader returns a copy of h with credentials hidden.
+func redactedHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range []string{"Authorization", "Cookie"} {
+		if h.Get(k) != "" {
+			h.Set(k, "REDACTED")
+		}
+	}
+	return h
+}
+
+// LoggingMiddleware logs each request and the status it got back at verbosity
+// level v.  Bearer tokens are never logged.
+func LoggingMiddleware(v glog.Level) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			glog.V(v).Infof("Request %s %s %v", req.Method, req.URL, redactedHeader(req.Header))
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				glog.V(v).Infof("Request %s %s failed: %+v", req.Method, req.URL, err)
+				return nil, err
+			}
+			glog.V(v).Infof("Response %s %s: %s", req.Method, req.URL, resp.Status)
+			return resp, nil
+		})
+	}
+}
+
+// TimingMiddleware calls observe with how long each request took to get its
+// response headers.  resp is nil if err is not.
+func TimingMiddleware(observe func(req *http.Request, resp *http.Response, err error, d time.Duration)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			observe(req, resp, err, time.Since(start))
+			return resp, err
+		})
+	}
+}
+
+// UserAgentMiddleware sends userAgent as the User-Agent of each request.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("User-Agent", userAgent)
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRedactedHeader(t *testing.T) {
+	h := http.Header{"Authorization": {"Bearer secret-token"}, "Accept": {"application/json"}}
+	r := redactedHeader(h)
+	if r.Get("Authorization") != "REDACTED" || r.Get("Accept") != "application/json" {
+		t.Errorf("Got headers %+v", r)
+	}
+	if h.Get("Authorization") != "Bearer secret-token" {
+		t.Errorf("Original headers changed: %+v", h)
+	}
+}
+
+// TestMiddleware checks the chain runs in order on token and API requests
+func TestMiddleware(t *testing.T) {
+	var agents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		if r.URL.Path == tokenPath {
+			w.Write([]byte(`{"access_token":"abc","expires_in":3600}`))
+			return
+		}
+		w.Write([]byte(`{"standard_groups":[]}`))
+	}))
+	defer ts.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	var timed []string
+	c := NewClient(ts.URL, "id", "secret", "user")
+	c.Middleware = []Middleware{
+		tag("outer"),
+		LoggingMiddleware(2),
+		TimingMiddleware(func(req *http.Request, resp *http.Response, err error, d time.Duration) {
+			if err != nil || d <= 0 {
+				t.Errorf("Timed %s in %s with error %+v", req.URL.Path, d, err)
+			}
+			timed = append(timed, req.URL.Path)
+		}),
+		UserAgentMiddleware("opened-go-test"),
+		tag("inner"),
+	}
+	if _, err := c.ListStandardGroups(context.Background()); err != nil {
+		t.Fatalf("Error from ListStandardGroups: %+v", err)
+	}
+	if want := []string{"outer", "inner", "outer", "inner"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Middleware ran in order %v, expected %v", order, want)
+	}
+	if want := []string{tokenPath, "/1/standard_groups.json"}; !reflect.DeepEqual(timed, want) {
+		t.Errorf("Timed %v, expected %v", timed, want)
+	}
+	for _, ua := range agents {
+		if ua != "opened-go-test" {
+			t.Errorf("Got User-Agent %q", ua)
+		}
+	}
+}
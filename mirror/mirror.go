@@ -0,0 +1,312 @@
+// Package mirror keeps an offline copy of the OpenEd catalog: resources,
+// standard groups, grade groups, categories and standards.  Each sync pulls
+// only the resources updated since the last one, so apps can serve catalog
+// reads from the mirror whether or not the partner API is up.
+//
+// Incremental syncs pass an updated_since parameter, an RFC 3339 time, to
+// /1/resources.json and assume the partner API then returns only resources
+// whose updated_at is at or after it.  The partner API documentation does not
+// promise this; openedtest.Server implements it that way.  Against a partner
+// that ignores the parameter an incremental sync fetches every resource, but
+// only a full sync reports deletes.
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	opened "github.com/openedinc/opened-go"
+)
+
+// Changes lists the IDs of the entries a sync added, updated and deleted.
+type Changes struct {
+	Added   []int
+	Updated []int
+	Deleted []int
+}
+
+// A Report describes what a sync changed.
+type Report struct {
+	// Full is set if every resource was fetched, not just the updated ones.
+	Full           bool
+	Resources      Changes
+	StandardGroups Changes
+	GradeGroups    Changes
+	Standards      Changes
+}
+
+// DefaultFullSyncEvery is how often a Mirror fetches every resource if its
+// FullSyncEvery is 0.
+const DefaultFullSyncEvery = 24 * time.Hour
+
+// syncOverlap is how far before the last sync an incremental sync asks for
+// updates from, so resources updated while it ran, or stamped by a partner
+// clock running behind ours, are not missed.
+const syncOverlap = 5 * time.Minute
+
+// A Mirror is a local copy of the partner catalog kept up to date by Sync.
+// Its read methods are safe to call while a sync is running.
+type Mirror struct {
+	Client *opened.Client
+	Store  Store
+	// FullSyncEvery is how often a sync fetches every resource instead of
+	// the updated ones, which is the only way to find deleted resources.
+	// If 0 DefaultFullSyncEvery is used; if negative only the first sync
+	// is full.
+	FullSyncEvery time.Duration
+
+	syncMu  sync.Mutex
+	mu      sync.RWMutex
+	catalog *Catalog
+}
+
+// Open returns a Mirror syncing from c into store, starting from the
+// catalog already in store.
+func Open(c *opened.Client, store Store) (*Mirror, error) {
+	catalog, err := store.Load()
+	if err != nil {
+		glog.Errorf("Error loading mirror: %+v", err)
+		return nil, err
+	}
+	if catalog == nil {
+		catalog = newCatalog()
+	}
+	return &Mirror{Client: c, Store: store, catalog: catalog}, nil
+}
+
+// Sync brings the mirror up to date with the partner API and saves it to the
+// store.  If it fails the mirror and its checkpoint are left as they were.
+func (m *Mirror) Sync(ctx context.Context) (Report, error) {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+	start := time.Now()
+	m.mu.RLock()
+	old := m.catalog
+	m.mu.RUnlock()
+
+	cp := old.Checkpoint
+	every := m.FullSyncEvery
+	if every == 0 {
+		every = DefaultFullSyncEvery
+	}
+	full := cp.FullSyncAt.IsZero() || (every > 0 && start.Sub(cp.FullSyncAt) >= every)
+	next := newCatalog()
+	if err := m.syncStandards(ctx, next); err != nil {
+		return Report{}, err
+	}
+	params := map[string]string{}
+	if !full {
+		for id, r := range old.Resources {
+			next.Resources[id] = r
+		}
+		params["updated_since"] = cp.SyncedAt.Add(-syncOverlap).UTC().Format(time.RFC3339)
+	}
+	it := m.Client.SearchResourcesIter(ctx, params, 0)
+	for it.Next() {
+		r := it.Resource()
+		next.Resources[r.ID] = r
+	}
+	if err := it.Err(); err != nil {
+		glog.Errorf("Error syncing resources: %+v", err)
+		return Report{}, err
+	}
+
+	next.Checkpoint = Checkpoint{SyncedAt: start, FullSyncAt: cp.FullSyncAt}
+	if full {
+		next.Checkpoint.FullSyncAt = start
+	}
+	if err := m.Store.Save(next); err != nil {
+		glog.Errorf("Error saving mirror: %+v", err)
+		return Report{}, err
+	}
+	m.mu.Lock()
+	m.catalog = next
+	m.mu.Unlock()
+
+	report := Report{
+		Full:           full,
+		Resources:      diff(resourceEntries(old), resourceEntries(next)),
+		StandardGroups: diff(standardGroupEntries(old), standardGroupEntries(next)),
+		GradeGroups:    diff(gradeGroupEntries(old), gradeGroupEntries(next)),
+		Standards:      diff(standardEntries(old), standardEntries(next)),
+	}
+	glog.V(1).Infof("Synced mirror in %s: %d resources added, %d updated, %d deleted",
+		time.Since(start), len(report.Resources.Added), len(report.Resources.Updated), len(report.Resources.Deleted))
+	return report, nil
+}
+
+// syncStandards fetches the whole standards tree into next.  It is small
+// enough that it is always fetched in full.
+func (m *Mirror) syncStandards(ctx context.Context, next *Catalog) error {
+	groups, err := m.Client.ListStandardGroups(ctx)
+	if err != nil {
+		return err
+	}
+	next.StandardGroups = groups.StandardGroups
+	for _, sg := range groups.StandardGroups {
+		gradeGroups, err := m.Client.ListGradeGroups(ctx, sg.ID)
+		if err != nil {
+			return err
+		}
+		next.GradeGroups[sg.ID] = gradeGroups.GradeGroups
+		for _, gg := range gradeGroups.GradeGroups {
+			categories, err := m.Client.ListCategories(ctx, gg.ID)
+			if err != nil {
+				return err
+			}
+			next.Categories[gg.ID] = categories.Categories
+			for _, cat := range categories.Categories {
+				standards, err := m.Client.ListStandards(ctx, cat.ID)
+				if err != nil {
+					return err
+				}
+				next.Standards[cat.ID] = standards.Standards
+			}
+		}
+	}
+	return nil
+}
+
+// entries maps the IDs of one kind of catalog entry to their JSON encodings,
+// which are compared to tell whether an entry changed.
+type entries map[int][]byte
+
+func (e entries) add(id int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		glog.Errorf("Error encoding %d: %+v", id, err)
+	}
+	e[id] = data
+}
+
+func resourceEntries(c *Catalog) entries {
+	e := entries{}
+	for id, r := range c.Resources {
+		e.add(id, r)
+	}
+	return e
+}
+
+func standardGroupEntries(c *Catalog) entries {
+	e := entries{}
+	for _, sg := range c.StandardGroups {
+		e.add(sg.ID, sg)
+	}
+	return e
+}
+
+func gradeGroupEntries(c *Catalog) entries {
+	e := entries{}
+	for _, ggs := range c.GradeGroups {
+		for _, gg := range ggs {
+			e.add(gg.ID, gg)
+		}
+	}
+	return e
+}
+
+func standardEntries(c *Catalog) entries {
+	e := entries{}
+	for _, ss := range c.Standards {
+		for _, s := range ss {
+			e.add(s.ID, s)
+		}
+	}
+	return e
+}
+
+// diff returns the IDs added, updated and deleted going from old to next, in order.
+func diff(old, next entries) Changes {
+	var ch Changes
+	for id, data := range next {
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			ch.Added = append(ch.Added, id)
+		case string(prev) != string(data):
+			ch.Updated = append(ch.Updated, id)
+		}
+	}
+	for id := range old {
+		if _, ok := next[id]; !ok {
+			ch.Deleted = append(ch.Deleted, id)
+		}
+	}
+	sort.Ints(ch.Added)
+	sort.Ints(ch.Updated)
+	sort.Ints(ch.Deleted)
+	return ch
+}
+
+// Checkpoint returns how far the mirror has synced.
+func (m *Mirror) Checkpoint() Checkpoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.catalog.Checkpoint
+}
+
+// Resource returns the mirrored resource with the given ID.
+func (m *Mirror) Resource(ID int) (opened.WsResource, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.catalog.Resources[ID]
+	return r, ok
+}
+
+// Resources returns every mirrored resource in order of ID.
+func (m *Mirror) Resources() []opened.WsResource {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	resources := make([]opened.WsResource, 0, len(m.catalog.Resources))
+	for _, r := range m.catalog.Resources {
+		resources = append(resources, r)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+	return resources
+}
+
+// StandardGroups lists the mirrored standard groups.
+func (m *Mirror) StandardGroups() opened.StandardGroupList {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return opened.StandardGroupList{StandardGroups: m.catalog.StandardGroups}
+}
+
+// GradeGroups lists the mirrored grade groups of a standard group.
+func (m *Mirror) GradeGroups(standardGroupID int) opened.GradeGroupList {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return opened.GradeGroupList{GradeGroups: m.catalog.GradeGroups[standardGroupID]}
+}
+
+// Categories lists the mirrored categories of a grade group.
+func (m *Mirror) Categories(gradeGroupID int) opened.CategoryList {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return opened.CategoryList{Categories: m.catalog.Categories[gradeGroupID]}
+}
+
+// Standards lists the mirrored standards of a category.
+func (m *Mirror) Standards(categoryID int) opened.StandardList {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return opened.StandardList{Standards: m.catalog.Standards[categoryID]}
+}
+
+// Standard returns the mirrored standard with the given identifier, such as K.CC.1.
+func (m *Mirror) Standard(identifier string) (opened.Standard, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, ss := range m.catalog.Standards {
+		for _, s := range ss {
+			if s.Identifier == identifier {
+				return s, true
+			}
+		}
+	}
+	return opened.Standard{}, false
+}
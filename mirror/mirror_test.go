@@ -0,0 +1,101 @@
+package mirror
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openedinc/opened-go/openedtest"
+)
+
+// TestSync checks a first full sync, an incremental one, reading the mirror
+// back from its store and a later full sync finding deletes
+func TestSync(t *testing.T) {
+	old := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	f := openedtest.DefaultFixtures()
+	for i := range f.Resources {
+		f.Resources[i].UpdatedAt = &old
+	}
+	server := openedtest.NewServer(f)
+	defer server.Close()
+	store := FileStore{Path: filepath.Join(t.TempDir(), "catalog.json")}
+	m, err := Open(server.Client(), store)
+	if err != nil {
+		t.Fatalf("Error from Open: %+v", err)
+	}
+	ctx := context.Background()
+
+	report, err := m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from Sync: %+v", err)
+	}
+	if !report.Full || len(report.Resources.Added) != 5 || len(report.StandardGroups.Added) != 2 ||
+		len(report.GradeGroups.Added) != 3 || len(report.Standards.Added) != 2 {
+		t.Errorf("First sync reported %+v", report)
+	}
+
+	// update one resource, update another stamped just before the first
+	// sync, add one, delete one and drop a standard
+	later := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	f.Resources[1].Title = "Counting to 30"
+	f.Resources[1].UpdatedAt = &later
+	behind := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	f.Resources[2].Title = "Counting to 40"
+	f.Resources[2].UpdatedAt = &behind
+	added := f.Resources[1]
+	added.ID, added.Title = 6, "Counting Backwards"
+	f.Resources = append(f.Resources[:4:4], added)
+	f.Standards[100] = f.Standards[100][:1]
+	server.SetFixtures(f)
+
+	report, err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from Sync: %+v", err)
+	}
+	want := Report{
+		Resources: Changes{Added: []int{6}, Updated: []int{2, 3}},
+		Standards: Changes{Deleted: []int{1001}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Incremental sync reported %+v, expected %+v", report, want)
+	}
+
+	// reads are served from the store without the partner
+	server.Close()
+	m2, err := Open(server.Client(), store)
+	if err != nil {
+		t.Fatalf("Error from Open: %+v", err)
+	}
+	if r, ok := m2.Resource(2); !ok || r.Title != "Counting to 30" {
+		t.Errorf("Got resource %+v", r)
+	}
+	if len(m2.Resources()) != 6 || len(m2.GradeGroups(1).GradeGroups) != 2 {
+		t.Errorf("Got resources %+v and grade groups %+v", m2.Resources(), m2.GradeGroups(1))
+	}
+	if s, ok := m2.Standard("K.CC.1"); !ok || s.ID != 1000 {
+		t.Errorf("Got standard %+v", s)
+	}
+	if !m2.Checkpoint().SyncedAt.Equal(m.Checkpoint().SyncedAt) {
+		t.Errorf("Checkpoint %+v not saved, expected %+v", m2.Checkpoint(), m.Checkpoint())
+	}
+	if _, err = m2.Sync(ctx); err == nil {
+		t.Errorf("Sync succeeded without the partner")
+	}
+	if _, ok := m2.Resource(6); !ok {
+		t.Errorf("Failed sync changed the mirror")
+	}
+
+	server = openedtest.NewServer(f)
+	defer server.Close()
+	m2.Client = server.Client()
+	m2.FullSyncEvery = time.Nanosecond
+	report, err = m2.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from Sync: %+v", err)
+	}
+	if !report.Full || !reflect.DeepEqual(report.Resources, Changes{Deleted: []int{5}}) {
+		t.Errorf("Full sync reported %+v", report)
+	}
+}
@@ -0,0 +1,95 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	opened "github.com/openedinc/opened-go"
+)
+
+// Checkpoint records how far the mirror has synced.
+type Checkpoint struct {
+	// SyncedAt is when the last successful sync started.  The next sync
+	// fetches only the resources updated since then.
+	SyncedAt time.Time `json:"synced_at"`
+	// FullSyncAt is when the last successful full sync started.
+	FullSyncAt time.Time `json:"full_sync_at"`
+}
+
+// A Catalog is a copy of the partner catalog.
+type Catalog struct {
+	Checkpoint     Checkpoint                `json:"checkpoint"`
+	Resources      map[int]opened.WsResource `json:"resources"`
+	StandardGroups []opened.StandardGroup    `json:"standard_groups"`
+	// GradeGroups are keyed by the ID of their standard group.
+	GradeGroups map[int][]opened.GradeGroup `json:"grade_groups"`
+	// Categories are keyed by the ID of their grade group.
+	Categories map[int][]opened.Category `json:"categories"`
+	// Standards are keyed by the ID of their category.
+	Standards map[int][]opened.Standard `json:"standards"`
+}
+
+func newCatalog() *Catalog {
+	return &Catalog{
+		Resources:   map[int]opened.WsResource{},
+		GradeGroups: map[int][]opened.GradeGroup{},
+		Categories:  map[int][]opened.Category{},
+		Standards:   map[int][]opened.Standard{},
+	}
+}
+
+// A Store keeps the mirrored catalog between runs.
+type Store interface {
+	// Load returns the saved catalog, or nil if none has been saved yet.
+	Load() (*Catalog, error)
+	Save(c *Catalog) error
+}
+
+// A FileStore keeps the catalog as JSON in the file at Path.
+type FileStore struct {
+	Path string
+}
+
+// Load reads the catalog from the file.
+func (f FileStore) Load() (*Catalog, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := newCatalog()
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("mirror: reading %s: %v", f.Path, err)
+	}
+	return c, nil
+}
+
+// Save replaces the file with c atomically, so a failed save leaves the
+// previous catalog in place.
+func (f FileStore) Save(c *Catalog) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package opened
+
+import (
+	"context"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// OAuth2TokenSource returns an oauth2.TokenSource handing out the tokens of
+// ts, so a partner TokenSource can be used with oauth2.NewClient and other
+// oauth2 tooling.  Tokens are fetched with ctx.
+func OAuth2TokenSource(ctx context.Context, ts TokenSource) oauth2.TokenSource {
+	return oauth2TokenSource{ctx, ts}
+}
+
+type oauth2TokenSource struct {
+	ctx context.Context
+	ts  TokenSource
+}
+
+func (s oauth2TokenSource) Token() (*oauth2.Token, error) {
+	tok, err := s.ts.Token(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{
+		AccessToken:  tok.AccessToken,
+		TokenType:    tok.TokenType,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+	}, nil
+}
+
+// FromOAuth2 returns a TokenSource handing out the tokens of an
+// oauth2.TokenSource, so any oauth2 flow can supply Client.TokenSource.
+func FromOAuth2(ts oauth2.TokenSource) TokenSource {
+	return fromOAuth2{ts}
+}
+
+type fromOAuth2 struct {
+	ts oauth2.TokenSource
+}
+
+func (s fromOAuth2) Token(ctx context.Context) (*Token, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tok, err := s.ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:  tok.AccessToken,
+		TokenType:    tok.TokenType,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+	}, nil
+}
+
+// OAuth2TokenSource returns the client's tokens as an oauth2.TokenSource.
+// With the default partner flow that is the client_id, secret and username
+// exchange with /1/oauth/get_token, refreshed as needed.
+func (c *Client) OAuth2TokenSource(ctx context.Context) oauth2.TokenSource {
+	return OAuth2TokenSource(ctx, c.tokenSource())
+}
+
+// ClientCredentials returns the configuration of a standard OAuth2 client
+// credentials flow for the client's ClientID and Secret against the partner
+// token endpoint.
+func (c *Client) ClientCredentials(scopes ...string) *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.Secret,
+		TokenURL:     c.BaseURL + tokenPath,
+		Scopes:       scopes,
+	}
+}
+
+// UseClientCredentials makes the client get its tokens with the standard
+// OAuth2 client credentials flow instead of the partner's username exchange.
+// Tokens are fetched with ctx through the client's HTTP client, so set its
+// HTTPClient and Middleware first, and call it before the client's first request.
+func (c *Client) UseClientCredentials(ctx context.Context, scopes ...string) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.httpClient())
+	c.TokenSource = FromOAuth2(c.ClientCredentials(scopes...).TokenSource(ctx))
+}
@@ -0,0 +1,58 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// TestOAuth2 checks the partner flow works with oauth2.NewClient and that a
+// client can use the client credentials flow
+func TestOAuth2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path != tokenPath:
+			if r.Header.Get("Authorization") != "Bearer partner-token" && r.Header.Get("Authorization") != "Bearer cc-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"standard_groups":[{"id":1}]}`))
+		case r.FormValue("grant_type") == "client_credentials":
+			id, secret, _ := r.BasicAuth()
+			if id == "" {
+				id, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+			}
+			if id != "id" || secret != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token":"cc-token","token_type":"bearer","expires_in":3600}`))
+		default:
+			w.Write([]byte(`{"access_token":"partner-token","token_type":"bearer","expires_in":3600}`))
+		}
+	}))
+	defer ts.Close()
+	ctx := context.Background()
+
+	c := NewClient(ts.URL, "id", "secret", "user")
+	hc := oauth2.NewClient(ctx, c.OAuth2TokenSource(ctx))
+	resp, err := hc.Get(ts.URL + "/1/standard_groups.json")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Got response %+v, error %+v", resp, err)
+	}
+	resp.Body.Close()
+
+	c = NewClient(ts.URL, "id", "secret", "")
+	c.UseClientCredentials(ctx)
+	tok, err := c.Token(ctx)
+	if err != nil || tok.AccessToken != "cc-token" || tok.Expiry.IsZero() {
+		t.Fatalf("Got token %+v, error %+v", tok, err)
+	}
+	if _, err = c.ListStandardGroups(ctx); err != nil {
+		t.Errorf("Error from ListStandardGroups: %+v", err)
+	}
+}
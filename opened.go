@@ -1,14 +1,13 @@
 // Package opened provides structures for OpenEd objects
-//such as resources and standards.
+// such as resources and standards.
 package opened
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"net/url"
 	"os"
 	"regexp"
@@ -19,7 +18,6 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/golang/glog"
-	"github.com/jmcvetta/napping"
 	"github.com/jmoiron/sqlx"
 	goredis "gopkg.in/redis.v3"
 )
@@ -29,17 +27,21 @@ type Resource struct {
 	ID             int
 	Title          sql.NullString
 	URL            sql.NullString `db:"share_url"`
-	PublisherID    sql.NullInt64 `db:"publisher_id"`
-	ContributionID sql.NullInt64 `db:"contribution_id"`
+	PublisherID    sql.NullInt64  `db:"publisher_id"`
+	ContributionID sql.NullInt64  `db:"contribution_id"`
 	Description    sql.NullString
 	ResourceTypeID sql.NullInt64  `db:"resource_type_id"`
 	YoutubeID      sql.NullString `db:"youtube_id"`
 	UsageCount     sql.NullInt64  `db:"usage_count"`
 	Effectiveness  string         `json:"effectiveness"`
 	Subject        string         `json:"subject"`
+	// Extra holds the partner API fields with no resources column, such as
+	// rating and standards, keyed by their JSON names, so a WsResource
+	// converted with ToResource converts back unchanged.
+	Extra map[string]json.RawMessage `db:"-" json:"-"`
 }
 
-// WsResource is web service queryParams for OpenEd resources (not all attributes in OpenEd).
+// WsResource is web service queryParams for OpenEd resources as returned by the partner API.
 type WsResource struct {
 	ID             int
 	Title          string
@@ -50,70 +52,92 @@ type WsResource struct {
 	ResourceTypeID int    `json:"resource_type_id"`
 	YoutubeID      string `json:"youtube_id"`
 	UseRightsURL   string `json:"use_rights_url"`
+	GradesRange    string `json:"grades_range"`
+	// Standards are the standards the resource is aligned to.
+	Standards    []Standard `json:"standards"`
+	Thumbnail    string     `json:"thumbnail"`
+	Rating       float64    `json:"rating"`
+	RatingsCount int        `json:"ratings_count"`
+	Subjects     []string   `json:"subjects"`
+	// Duration is the running time of a video or audio resource in seconds.
+	Duration  int        `json:"duration"`
+	Premium   bool       `json:"premium"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// Extra holds any fields of the payload not mapped above, so that fields
+	// added to the API later survive a decode and encode.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// db keeps the columns of a Resource converted with ToWsResource which
+	// have no partner API field.
+	db *dbFields
 }
 
 // ResourceList is a list of WSResources.
 type ResourceList struct {
 	Resources []WsResource
+	Meta      ListMeta `json:"meta"`
 }
 
 // SearchResources searches OpenEd for resources given set of queryParams.
+// It uses a Client configured from the environment (see NewClientFromEnv).
 func SearchResources(queryParams map[string]string, token string) (ResourceList, error) {
-	var err error
-	uri := os.Getenv("PARTNER_BASE_URI") + "/1/resources.json"
-	s := napping.Session{}
-	h := &http.Header{}
-	h.Set("Content-Type", "application/json")
-	h.Set("Authorization", "Bearer "+token)
-	s.Header = h
-	glog.V(2).Infof("Headers %+v", h)
-	var params napping.Params
-	params = napping.Params(queryParams)
-	p := params.AsUrlValues()
-	glog.V(2).Infof("Query parameters %+v", p)
-	resources := ResourceList{}
-	resp, err := s.Get(uri, &p, &resources, nil)
-	if err != nil {
-		glog.Fatal(err)
-	}
-	glog.V(2).Infof("Response: %s", resp.RawText())
-	return resources, err
+	return SearchResourcesContext(context.Background(), queryParams, token)
+}
+
+// SearchResourcesContext is like SearchResources but honors ctx for the request.
+func SearchResourcesContext(ctx context.Context, queryParams map[string]string, token string) (ResourceList, error) {
+	return tokenClient(token).SearchResources(ctx, queryParams)
+}
+
+// tokenClient returns a Client configured from the environment which uses token for every request.
+func tokenClient(token string) *Client {
+	c := NewClientFromEnv()
+	c.TokenSource = StaticTokenSource(token)
+	return c
 }
 
-// GetToken given a clientID and secret and username returns a token
+// GetToken given a clientID and secret and username returns a token.
+// Empty arguments fall back to the CLIENT_ID, CLIENT_SECRET, USERNAME and PARTNER_BASE_URI environment variables.
 func GetToken(clientID string, secret string, username string, uri string) (string, error) {
-	v := url.Values{}
-	if clientID == "" {
-		clientID = os.Getenv("CLIENT_ID")
+	return GetTokenContext(context.Background(), clientID, secret, username, uri)
+}
+
+// GetTokenContext is like GetToken but honors ctx for the request.
+func GetTokenContext(ctx context.Context, clientID string, secret string, username string, uri string) (string, error) {
+	c := NewClientFromEnv()
+	if clientID != "" {
+		c.ClientID = clientID
 	}
-	v.Set("client_id", clientID)
-	if secret == "" {
-		secret = os.Getenv("CLIENT_SECRET")
+	if secret != "" {
+		c.Secret = secret
 	}
-	v.Set("secret", secret)
-	if username == "" {
-		username = os.Getenv("USERNAME")
+	if username != "" {
+		c.Username = username
 	}
-	v.Set("username", username)
 	if uri == "" {
-		uri = os.Getenv("PARTNER_BASE_URI") + "/1/oauth/get_token"
+		uri = c.BaseURL + tokenPath
+	}
+	tok, err := c.requestToken(ctx, uri, "")
+	if err != nil {
+		return "", err
 	}
-	glog.V(1).Infof("Getting token for %s", clientID)
-	glog.V(1).Infof("To URL %s", uri)
-	resp, err := http.PostForm(uri, v)
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	var data map[string]string
-	json.Unmarshal(body, &data)
-	token := data["access_token"]
-	return token, err
+	return tok.AccessToken, nil
 }
 
+// resourceColumns are the resources columns read into a Resource.
+const resourceColumns = "ID,Title,Share_url,Publisher_id,Contribution_id,Description,Resource_type_id,Youtube_id"
+
 // GetResource fills a Resource structure with the values given the OpenEd resource_id
 func (resource *Resource) GetResource(db sqlx.DB) error {
-	query := "SELECT ID,Title,Publisher_id,Contribution_id,Description,Resource_type_id,Youtube_id FROM resources WHERE ID=" + strconv.Itoa(resource.ID)
+	return resource.GetResourceContext(context.Background(), db)
+}
+
+// GetResourceContext is like GetResource but honors ctx for the database query.
+func (resource *Resource) GetResourceContext(ctx context.Context, db sqlx.DB) error {
+	query := "SELECT " + resourceColumns + " FROM resources WHERE ID=" + strconv.Itoa(resource.ID)
 	glog.V(3).Infof("Querying with: %s", query)
-	err := db.Get(resource, query)
+	err := db.GetContext(ctx, resource, query)
 
 	if err != nil {
 		glog.Errorf("Error retrieving resource %d: %+v", resource.ID, err)
@@ -159,6 +183,7 @@ func (resource *Resource) GetResource(db sqlx.DB) error {
 );*/
 type Standard struct {
 	ID          int
+	Identifier  string `db:"identifier" json:"identifier"`
 	Grade       string
 	Title       string
 	Description string
@@ -166,9 +191,14 @@ type Standard struct {
 
 // GetStandard fills in fields in standards structure
 func (standard *Standard) GetStandard(db sqlx.DB) error {
+	return standard.GetStandardContext(context.Background(), db)
+}
+
+// GetStandardContext is like GetStandard but honors ctx for the database query.
+func (standard *Standard) GetStandardContext(ctx context.Context, db sqlx.DB) error {
 	var query string
 	query = "SELECT ID,Grade,Title,Description FROM Standards WHERE ID=" + strconv.Itoa(standard.ID)
-	err := db.Get(standard, query)
+	err := db.GetContext(ctx, standard, query)
 
 	if err != nil {
 		glog.Errorf("Error retrieving standards %d: %+v", standard.ID, err)
@@ -181,17 +211,22 @@ func (standard *Standard) GetStandard(db sqlx.DB) error {
 // ResourcesShareStandard tests if a supplied resources shares a standard with the
 // resource used.  Returns true if they share standards
 func (resource *Resource) ResourcesShareStandard(db sqlx.DB, resource2 Resource) bool {
+	return resource.ResourcesShareStandardContext(context.Background(), db, resource2)
+}
+
+// ResourcesShareStandardContext is like ResourcesShareStandard but honors ctx for the database queries.
+func (resource *Resource) ResourcesShareStandardContext(ctx context.Context, db sqlx.DB, resource2 Resource) bool {
 	queryBase := "SELECT standard_id FROM alignments WHERE resource_id="
 	query1 := queryBase + strconv.Itoa(resource.ID)
 	standards1 := []int{}
-	err := db.Select(&standards1, query1)
+	err := db.SelectContext(ctx, &standards1, query1)
 	if err != nil {
 		glog.Errorf("Couldn't retrieve standards for %d ", resource.ID)
 		return false
 	}
 	query2 := queryBase + strconv.Itoa(resource2.ID)
 	standards2 := []int{}
-	err = db.Select(&standards2, query2)
+	err = db.SelectContext(ctx, &standards2, query2)
 	if err != nil {
 		glog.Errorf("Couldn't retrieve standards for %d ", resource2.ID)
 		return false
@@ -211,11 +246,16 @@ func (resource *Resource) ResourcesShareStandard(db sqlx.DB, resource2 Resource)
 // ResourcesShareCategory tests if a supplied resources shares a standard category with the
 // resource used.  Returns true if they share category
 func (resource Resource) ResourcesShareCategory(db sqlx.DB, resource2 Resource) bool {
+	return resource.ResourcesShareCategoryContext(context.Background(), db, resource2)
+}
+
+// ResourcesShareCategoryContext is like ResourcesShareCategory but honors ctx for the database queries.
+func (resource Resource) ResourcesShareCategoryContext(ctx context.Context, db sqlx.DB, resource2 Resource) bool {
 	queryBase := "SELECT DISTINCT(category_id) FROM alignments INNER JOIN standards ON standards.ID=alignments.standard_id AND resource_id="
 	query1 := queryBase + strconv.Itoa(resource.ID)
 	categories1 := []int{}
 	glog.V(3).Infof("Querying categories for %d: %s", resource.ID, query1)
-	err := db.Select(&categories1, query1)
+	err := db.SelectContext(ctx, &categories1, query1)
 	if err != nil {
 		glog.Errorf("Couldn't retrieve categories for %d:%+v ", resource.ID, err)
 		return false
@@ -224,7 +264,7 @@ func (resource Resource) ResourcesShareCategory(db sqlx.DB, resource2 Resource)
 	query2 := queryBase + strconv.Itoa(resource2.ID)
 	categories2 := []int{}
 	glog.V(3).Infof("Querying categories for %d: %s", resource2.ID, query2)
-	err = db.Select(&categories2, query2)
+	err = db.SelectContext(ctx, &categories2, query2)
 	if err != nil {
 		glog.Errorf("Couldn't retrieve categories for %d ", resource2.ID)
 		return false
@@ -246,11 +286,16 @@ func (resource Resource) ResourcesShareCategory(db sqlx.DB, resource2 Resource)
 
 // ResourcesShareSubject checks if resource that is receiver and second resource share a subject
 func (resource Resource) ResourcesShareSubject(db sqlx.DB, resource2 Resource) bool {
+	return resource.ResourcesShareSubjectContext(context.Background(), db, resource2)
+}
+
+// ResourcesShareSubjectContext is like ResourcesShareSubject but honors ctx for the database queries.
+func (resource Resource) ResourcesShareSubjectContext(ctx context.Context, db sqlx.DB, resource2 Resource) bool {
 	queryBase := "SELECT subject_id FROM resources_subjects WHERE resources_subjects.resource_id="
 	query1 := queryBase + strconv.Itoa(resource.ID)
 	subjects1 := []int{}
 	glog.V(3).Infof("Querying subjects for %d: %s", resource.ID, query1)
-	err := db.Select(&subjects1, query1)
+	err := db.SelectContext(ctx, &subjects1, query1)
 	if err != nil {
 		glog.Errorf("Couldn't retrieve subjects for %d:%+v ", resource.ID, err)
 		return false
@@ -259,7 +304,7 @@ func (resource Resource) ResourcesShareSubject(db sqlx.DB, resource2 Resource) b
 	query2 := queryBase + strconv.Itoa(resource2.ID)
 	subjects2 := []int{}
 	glog.V(3).Infof("Querying subjects for %d: %s", resource2.ID, query2)
-	err = db.Select(&subjects2, query2)
+	err = db.SelectContext(ctx, &subjects2, query2)
 	if err != nil {
 		glog.Errorf("Couldn't retrieve categories for %d ", resource2.ID)
 		return false
@@ -291,10 +336,15 @@ type User struct {
 
 // ListUsers retrieves all users with assessments
 func ListUsers(db sqlx.DB) ([]User, error) {
+	return ListUsersContext(context.Background(), db)
+}
+
+// ListUsersContext is like ListUsers but honors ctx for the database query.
+func ListUsersContext(ctx context.Context, db sqlx.DB) ([]User, error) {
 	// retrieve only users with assessment runs
 	query := "SELECT distinct(users.ID),email,username,role,district_state,provider,grades_range FROM users INNER JOIN assessment_runs ON (users.ID=assessment_runs.user_id)"
 	users := []User{}
-	err := db.Select(&users, query)
+	err := db.SelectContext(ctx, &users, query)
 	if err != nil {
 		glog.Errorf("Error retrieving users: %v", err)
 		return nil, err
@@ -306,15 +356,20 @@ func ListUsers(db sqlx.DB) ([]User, error) {
 // An AssessmentRun has selected important information stored in OpenEd AssessmentRuns table.
 type AssessmentRun struct {
 	ID           int
-	UserID       int       `db:"user_id"`
-	FinishedAt   time.Time `db:"finished_at"`
-	AssessmentID int       `db:"assessment_id"`
-	Score        float32   `db:"score"`
-	FirstRun     bool      `db:"first_run"`
+	UserID       int       `db:"user_id" json:"user_id"`
+	FinishedAt   time.Time `db:"finished_at" json:"finished_at"`
+	AssessmentID int       `db:"assessment_id" json:"assessment_id"`
+	Score        float32   `db:"score" json:"score"`
+	FirstRun     bool      `db:"first_run" json:"first_run"`
 }
 
 // ListAssessmentRuns shows all assessment runs in database for a given grade
 func ListAssessmentRuns(db sqlx.DB, grade string) ([]AssessmentRun, error) {
+	return ListAssessmentRunsContext(context.Background(), db, grade)
+}
+
+// ListAssessmentRunsContext is like ListAssessmentRuns but honors ctx for the database query.
+func ListAssessmentRunsContext(ctx context.Context, db sqlx.DB, grade string) ([]AssessmentRun, error) {
 	// retrieve only users with assessment runs
 	query := `SELECT distinct(a.id),a.user_id,a.finished_at,a.assessment_id,a.score,a.first_run
 		FROM assessment_runs a INNER JOIN resources ON resources.ID=a.ID
@@ -327,7 +382,7 @@ func ListAssessmentRuns(db sqlx.DB, grade string) ([]AssessmentRun, error) {
 	}
 	glog.V(2).Infof("Query for assessment runs: %s", query)
 	runs := []AssessmentRun{}
-	err := db.Select(&runs, query)
+	err := db.SelectContext(ctx, &runs, query)
 	if err != nil {
 		glog.Errorf("Error retrieving run: %+v", err)
 		return nil, err
@@ -346,9 +401,14 @@ type Alignment struct {
 
 // GetAlignments retrieves all standard alignments for a given resource
 func (resource Resource) GetAlignments(db sqlx.DB) []int {
+	return resource.GetAlignmentsContext(context.Background(), db)
+}
+
+// GetAlignmentsContext is like GetAlignments but honors ctx for the database query.
+func (resource Resource) GetAlignmentsContext(ctx context.Context, db sqlx.DB) []int {
 	query := "SELECT standard_id FROM alignments WHERE resource_id=" + strconv.Itoa(resource.ID)
 	standards := []int{}
-	err := db.Select(&standards, query)
+	err := db.SelectContext(ctx, &standards, query)
 	if err != nil {
 		glog.Errorf("Error retrieving standards: %+v", err)
 		return nil
@@ -382,26 +442,12 @@ type StandardGroupList struct {
 
 // ListStandardGroups lists all of the standard groups
 func ListStandardGroups(token string) (StandardGroupList, error) {
-	// SearchResources searches OpenEd for resources given set of queryParams.
-	var err error
-	uri := os.Getenv("PARTNER_BASE_URI") + "/1/standard_groups.json"
-	s := napping.Session{}
-	h := &http.Header{}
-	h.Set("Content-Type", "application/json")
-	h.Set("Authorization", "Bearer "+token)
-	s.Header = h
-	glog.V(2).Infof("Headers %+v", h)
-	groups := StandardGroupList{}
-	glog.V(2).Infof("Hitting URI %s", uri)
-
-	resp, err := s.Get(uri, nil, &groups, nil)
-	if err != nil {
-		glog.V(2).Infof("Error: %+v", err)
-		glog.Fatal(err)
-	}
-	glog.V(2).Infof("Response: %s", resp.RawText())
-	glog.V(2).Infof("Groups: %+v", groups)
-	return groups, err
+	return ListStandardGroupsContext(context.Background(), token)
+}
+
+// ListStandardGroupsContext is like ListStandardGroups but honors ctx for the request.
+func ListStandardGroupsContext(ctx context.Context, token string) (StandardGroupList, error) {
+	return tokenClient(token).ListStandardGroups(ctx)
 }
 
 // GradeGroup has info on a grade group such as Elementary
@@ -416,38 +462,23 @@ type GradeGroupList struct {
 	GradeGroups []GradeGroup `json:"grade_groups"`
 }
 
-// ListGradeGroups lists all of the standard groups
+// ListGradeGroups lists all of the grade groups for a standard group
 func ListGradeGroups(ID int, token string) (GradeGroupList, error) {
-	// SearchResources searches OpenEd for resources given set of queryParams.
-	var err error
-	uri := os.Getenv("PARTNER_BASE_URI") + "/1/grade_groups.json"
-	s := napping.Session{}
-	h := &http.Header{}
-	h.Set("Content-Type", "application/json")
-	h.Set("Authorization", "Bearer "+token)
-	s.Header = h
-	glog.V(2).Infof("Headers %+v", h)
-	groups := GradeGroupList{}
-	glog.V(2).Infof("Hitting URI %s", uri)
-
-	var params napping.Params
-	params = napping.Params{}
-	params["standard_group"] = strconv.Itoa(ID)
-	p := params.AsUrlValues()
-	glog.V(2).Infof("Query parameters %+v", p)
-
-	resp, err := s.Get(uri, &p, &groups, nil)
-	if err != nil {
-		glog.V(2).Infof("Error: %+v", err)
-		glog.Fatal(err)
-	}
-	glog.V(2).Infof("Response: %s", resp.RawText())
-	glog.V(2).Infof("Groups: %+v", groups)
-	return groups, err
+	return ListGradeGroupsContext(context.Background(), ID, token)
+}
+
+// ListGradeGroupsContext is like ListGradeGroups but honors ctx for the request.
+func ListGradeGroupsContext(ctx context.Context, ID int, token string) (GradeGroupList, error) {
+	return tokenClient(token).ListGradeGroups(ctx, ID)
 }
 
 // DumpResourceRatings writes a file with each resource and its rating for each standard
 func DumpResourceRatings(db *sqlx.DB, grade string) (numRatings int, err error) {
+	return DumpResourceRatingsContext(context.Background(), db, grade)
+}
+
+// DumpResourceRatingsContext is like DumpResourceRatings but honors ctx for the Redis scan, database queries and S3 upload.
+func DumpResourceRatingsContext(ctx context.Context, db *sqlx.DB, grade string) (numRatings int, err error) {
 	redisConnect := os.Getenv("REDIS_URL")
 	redisURL, _ := url.Parse(redisConnect)
 	redisPassword := ""
@@ -467,6 +498,10 @@ func DumpResourceRatings(db *sqlx.DB, grade string) (numRatings int, err error)
 	content = "Resource,Rating\n"
 
 	for {
+		// the Redis client has no context support so check between scans
+		if err = ctx.Err(); err != nil {
+			return numRatings, err
+		}
 		cursor, keys, err = c.Scan(cursor, "resource:*", 10).Result()
 		if err != nil {
 			glog.Fatalf("Scan error: %s", err)
@@ -486,14 +521,14 @@ func DumpResourceRatings(db *sqlx.DB, grade string) (numRatings int, err error)
 			id, _ := strconv.Atoi(string(resNum))
 			r := Resource{ID: id}
 			rp := &r
-			err = rp.GetResource(*db)
+			err = rp.GetResourceContext(ctx, *db)
 
 			content = content + fmt.Sprintf("%s,", r.URL.String)
 			for stdID, rating := range ratings.Val() {
 				ID, _ := strconv.Atoi(stdID)
 				s := Standard{ID: ID}
 				sp := &s
-				err = sp.GetStandard(*db)
+				err = sp.GetStandardContext(ctx, *db)
 				content = content + fmt.Sprintf("%s,%s,", s.Title, rating)
 			}
 			content = content + "\n"
@@ -503,12 +538,17 @@ func DumpResourceRatings(db *sqlx.DB, grade string) (numRatings int, err error)
 	glog.V(1).Infof("Found %d keys\n", n)
 	filename := fmt.Sprintf("%s-%s", grade, "ratings.csv")
 	glog.V(1).Infof("Writing result to %s\n", filename)
-	S3WriteFile(filename, content)
+	S3WriteFileContext(ctx, filename, content)
 	return numRatings, err
 }
 
 // S3WriteFile write specified content to file with filename
 func S3WriteFile(filename string, content string) error {
+	return S3WriteFileContext(context.Background(), filename, content)
+}
+
+// S3WriteFileContext is like S3WriteFile but honors ctx for the S3 upload.
+func S3WriteFileContext(ctx context.Context, filename string, content string) error {
 	glog.V(2).Infof("Write to file %s: %s", filename, content)
 	svc := s3.New(session.New(), &aws.Config{Region: aws.String("us-east-1")}) // implicit works with AWS_ACCESS__KEY_ID and AWS_SECRET_ACCESS_KEY
 	bucket := os.Getenv("AWS_S3_BUCKET")
@@ -518,7 +558,7 @@ func S3WriteFile(filename string, content string) error {
 		Key:    &filename,
 		Body:   bytes.NewReader([]byte(content)),
 	}
-	_, putErr := svc.PutObject(&putParams)
+	_, putErr := svc.PutObjectWithContext(ctx, &putParams)
 	if putErr == nil {
 		glog.V(2).Infof("Wrote content: %+v", content)
 	} else {
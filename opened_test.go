@@ -46,58 +46,6 @@ func TestListAssessmentRuns(t *testing.T) {
 	teardown(db)
 }
 
-func TestListStandardGroups(t *testing.T) {
-	token, _ := setupWs()
-	results, err := ListStandardGroups(token)
-	if err != nil {
-		t.Errorf("Error from ListStandardGroups: %+v", err)
-	}
-	glog.V(1).Infof("%d groups returned", len(results.StandardGroups))
-}
-
-func TestListGradeGroups(t *testing.T) {
-	token, _ := setupWs()
-	sgResults, err := ListStandardGroups(token)
-	results, err := ListGradeGroups(sgResults.StandardGroups[0].ID, token)
-	if err != nil {
-		t.Errorf("Error from ListGradeGroups: %+v", err)
-	}
-	glog.V(1).Infof("%d grade groups returned", len(results.GradeGroups))
-}
-
-// TestSearchResources calls SearchResources with some query parameters and checks if it gets back results
-func TestSearchResources(t *testing.T) {
-	token, _ := setupWs()
-	queryParams := make(map[string]string)
-	queryParams["descriptive"] = "counting"
-	queryParams["grades_range"] = "K-1"
-	results, err := SearchResources(queryParams, token)
-	if err != nil {
-		t.Errorf("Error from SearchResources: %+v", err)
-	}
-	glog.V(1).Infof("%d results returned", len(results.Resources))
-	glog.V(2).Infof("First result: %+v", results.Resources[0])
-}
-
-// setup_ws sets up test for OpenEd package calls which use web services (instead of database)
-func setupWs() (string, error) {
-	flag.Set("alsologtostderr", "true")
-	flag.Set("v", "3")
-	token, err := GetToken("", "", "", "")
-	return token, err
-}
-
-func TestGetToken(t *testing.T) {
-	flag.Set("alsologtostderr", "true")
-	flag.Set("v", "3")
-	token, err := GetToken("", "", "", "")
-	if err != nil {
-		t.Errorf("Failed to get token: %+v", err)
-	} else {
-		glog.V(1).Infof("Got token %s", token)
-	}
-}
-
 func TestListUsers(t *testing.T) {
 	db := setup()
 	users, err := ListUsers(*db)
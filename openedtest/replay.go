@@ -0,0 +1,306 @@
+package openedtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// redacted replaces secrets in recorded interactions.
+const redacted = "REDACTED"
+
+// secretKeys are form fields and JSON keys whose values are never recorded.
+var secretKeys = map[string]bool{
+	"client_id":     true,
+	"secret":        true,
+	"client_secret": true,
+	"username":      true,
+	"password":      true,
+	"access_token":  true,
+	"refresh_token": true,
+}
+
+// An Interaction is one recorded request to the partner API and its response.
+// The Bearer token and credentials are scrubbed before it is recorded.
+type Interaction struct {
+	Method string `json:"method"`
+	// URL is the path and sorted query of the request, without the host.
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        string      `json:"body"`
+}
+
+// requestKey returns the scrubbed method, URL and body used to match a request.
+func requestKey(req *http.Request, body []byte) (string, string, string) {
+	u := req.URL.Path
+	if q := req.URL.Query(); len(q) > 0 {
+		u = u + "?" + q.Encode()
+	}
+	return req.Method, u, scrub(req.Header.Get("Content-Type"), body)
+}
+
+// scrub replaces secret values in a form or JSON body.
+func scrub(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		v, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		for k := range v {
+			if secretKeys[k] {
+				v.Set(k, redacted)
+			}
+		}
+		return v.Encode()
+	}
+	var data interface{}
+	if json.Unmarshal(body, &data) != nil {
+		return string(body)
+	}
+	scrubJSON(data)
+	out, err := json.Marshal(data)
+	if err != nil {
+		return string(body)
+	}
+	return string(out)
+}
+
+func scrubJSON(data interface{}) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			if secretKeys[k] {
+				v[k] = redacted
+			} else {
+				scrubJSON(x)
+			}
+		}
+	case []interface{}:
+		for _, x := range v {
+			scrubJSON(x)
+		}
+	}
+}
+
+// requestBody returns the body of req, leaving it readable by the transport.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return readBody(&req.Body)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// readBody reads and replaces a body so it can be read again.
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil {
+		return nil, nil
+	}
+	data, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, err
+}
+
+// A Recorder is an http.RoundTripper which sends requests on through
+// Transport and records each interaction.
+type Recorder struct {
+	// Transport sends the requests.  If nil http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// RoundTrip sends req and records it with its response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	method, u, reqText := requestKey(req, reqBody)
+	header := resp.Header.Clone()
+	header.Del("Date")
+	header.Del("Set-Cookie")
+	header.Del("Content-Length")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:      method,
+		URL:         u,
+		RequestBody: reqText,
+		Status:      resp.StatusCode,
+		Header:      header,
+		Body:        scrub(resp.Header.Get("Content-Type"), body),
+	})
+	return resp, nil
+}
+
+// Interactions returns the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the golden file at path.
+func (r *Recorder) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.Interactions()); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// A Replayer is an http.RoundTripper which answers requests from recorded
+// interactions without touching the network.  Each interaction is played once,
+// in the order recorded for requests that match it.  A request which matches
+// no remaining interaction fails with an error describing it.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	played       []bool
+}
+
+// NewReplayer returns a Replayer for interactions.
+func NewReplayer(interactions []Interaction) *Replayer {
+	return &Replayer{interactions: interactions, played: make([]bool, len(interactions))}
+}
+
+// LoadReplayer returns a Replayer for the golden file at path.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err = json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("openedtest: reading %s: %v", path, err)
+	}
+	return NewReplayer(interactions), nil
+}
+
+// RoundTrip answers req with the first unplayed matching interaction.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	method, u, reqText := requestKey(req, reqBody)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.played[i] || in.Method != method || in.URL != u || in.RequestBody != reqText {
+			continue
+		}
+		r.played[i] = true
+		header := in.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(in.Body)),
+			ContentLength: int64(len(in.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("openedtest: no recorded interaction matches %s %s %s", method, u, reqText)
+}
+
+// Unplayed returns the recorded interactions no request has matched yet.
+func (r *Replayer) Unplayed() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unplayed []Interaction
+	for i, in := range r.interactions {
+		if !r.played[i] {
+			unplayed = append(unplayed, in)
+		}
+	}
+	return unplayed
+}
+
+// RecordEnv is the environment variable which switches Transport to recording.
+const RecordEnv = "OPENED_RECORD"
+
+// Transport returns a RoundTripper for a test backed by the golden file at path.
+// Normally it replays the file and fails the test if any request does not
+// match a recorded interaction or any interaction is left unplayed.  If
+// OPENED_RECORD is set it sends requests to the real partner instead and
+// rewrites the file when the test ends.
+func Transport(t testing.TB, path string) http.RoundTripper {
+	if os.Getenv(RecordEnv) != "" {
+		rec := &Recorder{}
+		t.Cleanup(func() {
+			if err := rec.Save(path); err != nil {
+				t.Errorf("Failed to save %s: %+v", path, err)
+			}
+		})
+		return rec
+	}
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("Failed to load %s: %+v", path, err)
+	}
+	t.Cleanup(func() {
+		for _, in := range rep.Unplayed() {
+			t.Errorf("Recorded interaction not replayed: %s %s", in.Method, in.URL)
+		}
+	})
+	return failLoudly{t, rep}
+}
+
+// failLoudly fails the test on any request the replayer cannot answer.
+type failLoudly struct {
+	t   testing.TB
+	rep *Replayer
+}
+
+func (f failLoudly) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := f.rep.RoundTrip(req)
+	if err != nil {
+		f.t.Errorf("%v", err)
+	}
+	return resp, err
+}
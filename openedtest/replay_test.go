@@ -0,0 +1,59 @@
+package openedtest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	opened "github.com/openedinc/opened-go"
+)
+
+// TestRecordReplay records a session against the fake partner and replays it
+// with the partner gone
+func TestRecordReplay(t *testing.T) {
+	server := NewServer(DefaultFixtures())
+	rec := &Recorder{}
+	c := server.Client()
+	c.HTTPClient = &http.Client{Transport: rec}
+	ctx := context.Background()
+	live, err := c.SearchResources(ctx, map[string]string{"descriptive": "counting"})
+	if err != nil {
+		t.Fatalf("Error from SearchResources: %+v", err)
+	}
+	if _, err = c.ListStandardGroups(ctx); err != nil {
+		t.Fatalf("Error from ListStandardGroups: %+v", err)
+	}
+	server.Close()
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err = rec.Save(path); err != nil {
+		t.Fatalf("Failed to save: %+v", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	for _, secret := range []string{Secret, ClientID, "token-1"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Recording contains %s: %s", secret, data)
+		}
+	}
+
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("Failed to load: %+v", err)
+	}
+	c = opened.NewClient("https://partner.example.com", ClientID, Secret, Username)
+	c.HTTPClient = &http.Client{Transport: rep}
+	c.RetryPolicy = &opened.NoRetry
+	replayed, err := c.SearchResources(ctx, map[string]string{"descriptive": "counting"})
+	if err != nil || len(replayed.Resources) != len(live.Resources) {
+		t.Errorf("Replayed %+v, error %+v", replayed, err)
+	}
+	if len(rep.Unplayed()) != 1 {
+		t.Errorf("Unplayed interactions: %+v", rep.Unplayed())
+	}
+	if _, err = c.SearchResources(ctx, map[string]string{"descriptive": "shapes"}); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("Unrecorded request got error %+v", err)
+	}
+}
@@ -0,0 +1,288 @@
+// Package openedtest provides an in-process fake of the OpenEd partner API
+// so that code using opened.Client can be tested offline.
+package openedtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	opened "github.com/openedinc/opened-go"
+)
+
+// Credentials accepted by a Server unless its fields are changed.
+const (
+	ClientID = "test-client"
+	Secret   = "test-secret"
+	Username = "test-user"
+)
+
+// Fixtures is the catalog a Server serves.
+type Fixtures struct {
+	Resources      []opened.WsResource
+	StandardGroups []opened.StandardGroup
+	// GradeGroups are keyed by the ID of their standard group.
+	GradeGroups map[int][]opened.GradeGroup
+	// Categories are keyed by the ID of their grade group.
+	Categories map[int][]opened.Category
+	// Standards are keyed by the ID of their category.
+	Standards map[int][]opened.Standard
+}
+
+// DefaultFixtures returns a small catalog of kindergarten math.
+func DefaultFixtures() Fixtures {
+	return Fixtures{
+		Resources: []opened.WsResource{
+			{ID: 1, Title: "Counting to 10", GradesRange: "K", ResourceTypeID: 1, YoutubeID: "a1"},
+			{ID: 2, Title: "Counting to 20", GradesRange: "K-1", ResourceTypeID: 1, YoutubeID: "a2"},
+			{ID: 3, Title: "Counting Game", GradesRange: "K-1", ResourceTypeID: 2},
+			{ID: 4, Title: "Adding within 5", GradesRange: "K-1", ResourceTypeID: 1, YoutubeID: "a4"},
+			{ID: 5, Title: "Shapes Around Us", GradesRange: "K", ResourceTypeID: 1, YoutubeID: "a5"},
+		},
+		StandardGroups: []opened.StandardGroup{
+			{ID: 1, Title: "Common Core Math", GradesRange: "K-12", AreaID: 1},
+			{ID: 2, Title: "Common Core ELA", GradesRange: "K-12", AreaID: 2},
+		},
+		GradeGroups: map[int][]opened.GradeGroup{
+			1: {{ID: 10, Title: "Kindergarten", GradesRange: "K"}, {ID: 11, Title: "Grade 1", GradesRange: "1"}},
+			2: {{ID: 20, Title: "Kindergarten", GradesRange: "K"}},
+		},
+		Categories: map[int][]opened.Category{
+			10: {{ID: 100, Title: "Counting and Cardinality", GradeGroupID: 10}},
+		},
+		Standards: map[int][]opened.Standard{
+			100: {
+				{ID: 1000, Identifier: "K.CC.1", Grade: "K", Title: "Count to 100 by ones and by tens."},
+				{ID: 1001, Identifier: "K.CC.2", Grade: "K", Title: "Count forward from a given number."},
+			},
+		},
+	}
+}
+
+// failure is an injected failure for the next requests to a path.
+type failure struct {
+	status int
+	times  int
+}
+
+// A Server is a fake partner API serving /1/oauth/get_token, /1/resources.json,
+// /1/standard_groups.json, /1/grade_groups.json, /1/categories.json and
+// /1/standards.json from its Fixtures.
+// Failures and latency can be injected to test error handling.
+type Server struct {
+	*httptest.Server
+	ClientID string
+	Secret   string
+	Username string
+	// TokenLifetime is reported as expires_in for issued tokens.
+	TokenLifetime time.Duration
+
+	mu       sync.Mutex
+	fixtures Fixtures
+	tokens   map[string]bool
+	issued   int
+	latency  time.Duration
+	failures map[string]*failure
+	requests map[string]int
+}
+
+// NewServer starts a Server serving f.  Close it when done.
+func NewServer(f Fixtures) *Server {
+	s := &Server{
+		ClientID:      ClientID,
+		Secret:        Secret,
+		Username:      Username,
+		TokenLifetime: time.Hour,
+		fixtures:      f,
+		tokens:        map[string]bool{},
+		failures:      map[string]*failure{},
+		requests:      map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns an opened.Client for the server using its credentials.
+func (s *Server) Client() *opened.Client {
+	return opened.NewClient(s.URL, s.ClientID, s.Secret, s.Username)
+}
+
+// SetFixtures replaces the catalog the server serves.
+func (s *Server) SetFixtures(f Fixtures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures = f
+}
+
+// Fail makes the next times requests to path, such as /1/resources.json,
+// fail with status.  A 429 comes with a Retry-After of 0 seconds.
+func (s *Server) Fail(path string, status int, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = &failure{status: status, times: times}
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// RevokeTokens makes every token issued so far be rejected with 401.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]bool{}
+}
+
+// Requests returns how many requests were made to path, including failed ones.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	latency := s.latency
+	fixtures := s.fixtures
+	f := s.failures[r.URL.Path]
+	status := 0
+	if f != nil && f.times > 0 {
+		f.times--
+		status = f.status
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if status != 0 {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		writeError(w, status, "injected failure")
+		return
+	}
+	if r.URL.Path == "/1/oauth/get_token" {
+		s.getToken(w, r)
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	switch r.URL.Path {
+	case "/1/resources.json":
+		searchResources(w, r, fixtures.Resources)
+	case "/1/standard_groups.json":
+		writeJSON(w, opened.StandardGroupList{StandardGroups: fixtures.StandardGroups})
+	case "/1/grade_groups.json":
+		id, _ := strconv.Atoi(r.FormValue("standard_group"))
+		writeJSON(w, opened.GradeGroupList{GradeGroups: fixtures.GradeGroups[id]})
+	case "/1/categories.json":
+		id, _ := strconv.Atoi(r.FormValue("grade_group"))
+		writeJSON(w, opened.CategoryList{Categories: fixtures.Categories[id]})
+	case "/1/standards.json":
+		id, _ := strconv.Atoi(r.FormValue("category"))
+		writeJSON(w, opened.StandardList{Standards: fixtures.Standards[id]})
+	default:
+		writeError(w, http.StatusNotFound, "no route for "+r.URL.Path)
+	}
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	if r.FormValue("client_id") != s.ClientID || r.FormValue("secret") != s.Secret || r.FormValue("username") != s.Username {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+	s.mu.Lock()
+	s.issued++
+	token := fmt.Sprintf("token-%d", s.issued)
+	s.tokens[token] = true
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   int(s.TokenLifetime / time.Second),
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[token]
+}
+
+// searchResources matches descriptive against titles and descriptions,
+// grades_range exactly and updated_since against updated_at, keeping the
+// resources updated at or after it, then pages the matches with limit and
+// offset.  Resources without updated_at always match updated_since.
+func searchResources(w http.ResponseWriter, r *http.Request, resources []opened.WsResource) {
+	descriptive := strings.ToLower(r.FormValue("descriptive"))
+	grades := r.FormValue("grades_range")
+	var since time.Time
+	if v := r.FormValue("updated_since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "bad updated_since")
+			return
+		}
+	}
+	matches := []opened.WsResource{}
+	for _, res := range resources {
+		text := strings.ToLower(res.Title + " " + res.Description)
+		if descriptive != "" && !strings.Contains(text, descriptive) {
+			continue
+		}
+		if grades != "" && res.GradesRange != grades {
+			continue
+		}
+		if !since.IsZero() && res.UpdatedAt != nil && res.UpdatedAt.Before(since) {
+			continue
+		}
+		matches = append(matches, res)
+	}
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(r.FormValue("offset"))
+	list := opened.ResourceList{Resources: []opened.WsResource{}}
+	list.Meta.Pagination = opened.Pagination{Count: len(matches), Limit: limit, Offset: offset}
+	if offset < len(matches) {
+		end := offset + limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		list.Resources = matches[offset:end]
+	}
+	writeJSON(w, list)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,173 @@
+package opened
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// maxPageSize is the largest page the partner API will return.
+const maxPageSize = 100
+
+// sortOrders are the values the partner API accepts for sort.
+var sortOrders = map[string]bool{
+	"":           true,
+	"relevance":  true,
+	"rating":     true,
+	"title":      true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// A SearchQuery is a typed resource search against /1/resources.json.
+// Empty fields are left out of the query.
+type SearchQuery struct {
+	// Descriptive is free text matched against titles and descriptions.
+	Descriptive string
+	// StandardIDs restricts results to resources aligned to these standards.
+	StandardIDs []int
+	// Standards restricts results by standard identifier such as K.CC.1
+	Standards []string
+	// MinGrade and MaxGrade give the grade range, each K or 1 to 12.
+	// If only one is set the range is that single grade.
+	MinGrade string
+	MaxGrade string
+	// ResourceTypes such as video, game or assessment.
+	ResourceTypes []string
+	Subject       string
+	Publisher     string
+	// Sort is one of relevance, rating, title, created_at or updated_at.
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+// gradeNumber converts a grade such as K or 5 to a number, with K as 0.
+func gradeNumber(grade string) (int, error) {
+	if strings.EqualFold(grade, "K") {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(grade)
+	if err != nil || n < 1 || n > 12 {
+		return 0, fmt.Errorf("opened: invalid grade %q", grade)
+	}
+	return n, nil
+}
+
+// gradesRange returns the grades_range parameter, such as K-2, for the query.
+func (q SearchQuery) gradesRange() string {
+	min, max := strings.ToUpper(q.MinGrade), strings.ToUpper(q.MaxGrade)
+	if min == "" {
+		min = max
+	}
+	if max == "" {
+		max = min
+	}
+	if min == "" {
+		return ""
+	}
+	return min + "-" + max
+}
+
+// Validate checks that the query only asks for things the partner API understands.
+func (q SearchQuery) Validate() error {
+	if q.MinGrade != "" || q.MaxGrade != "" {
+		r := strings.SplitN(q.gradesRange(), "-", 2)
+		min, err := gradeNumber(r[0])
+		if err != nil {
+			return err
+		}
+		max, err := gradeNumber(r[1])
+		if err != nil {
+			return err
+		}
+		if min > max {
+			return fmt.Errorf("opened: grade range %s is backwards", q.gradesRange())
+		}
+	}
+	for _, id := range q.StandardIDs {
+		if id <= 0 {
+			return fmt.Errorf("opened: invalid standard ID %d", id)
+		}
+	}
+	for _, t := range q.ResourceTypes {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("opened: empty resource type")
+		}
+	}
+	if !sortOrders[q.Sort] {
+		return fmt.Errorf("opened: invalid sort %q", q.Sort)
+	}
+	if q.Limit < 0 || q.Limit > maxPageSize {
+		return fmt.Errorf("opened: limit %d is not between 0 and %d", q.Limit, maxPageSize)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("opened: negative offset %d", q.Offset)
+	}
+	return nil
+}
+
+// Values returns the query parameters for the search.
+func (q SearchQuery) Values() url.Values {
+	v := url.Values{}
+	set := func(key string, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	set("descriptive", q.Descriptive)
+	if len(q.StandardIDs) > 0 {
+		ids := make([]string, len(q.StandardIDs))
+		for i, id := range q.StandardIDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		set("standard_ids", strings.Join(ids, ","))
+	}
+	set("standard", strings.Join(q.Standards, ","))
+	set("grades_range", q.gradesRange())
+	set("resource_types", strings.Join(q.ResourceTypes, ","))
+	set("subject", q.Subject)
+	set("publisher", q.Publisher)
+	set("sort", q.Sort)
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	return v
+}
+
+// Encode returns the query string for the search.
+func (q SearchQuery) Encode() string {
+	return q.Values().Encode()
+}
+
+// Search searches OpenEd for resources matching q, returning one page of results.
+func (c *Client) Search(ctx context.Context, q SearchQuery) (ResourceList, error) {
+	if err := q.Validate(); err != nil {
+		return ResourceList{}, err
+	}
+	p := q.Values()
+	glog.V(2).Infof("Query parameters %+v", p)
+	resources := ResourceList{}
+	if err := c.get(ctx, "/1/resources.json", p, &resources); err != nil {
+		glog.Errorf("Error searching resources: %+v", err)
+		return ResourceList{}, err
+	}
+	return resources, nil
+}
+
+// SearchIter returns a ResourceIterator over all results matching q.
+// At most maxResults resources are returned; 0 means no cap.
+// If q is invalid the iterator stops at once and its Err says why.
+func (c *Client) SearchIter(ctx context.Context, q SearchQuery, maxResults int) *ResourceIterator {
+	if err := q.Validate(); err != nil {
+		return &ResourceIterator{err: err}
+	}
+	return newResourceIterator(ctx, c, q.Values(), maxResults)
+}
@@ -0,0 +1,42 @@
+package opened
+
+import (
+	"testing"
+)
+
+func TestSearchQueryEncode(t *testing.T) {
+	q := SearchQuery{
+		Descriptive:   "counting",
+		StandardIDs:   []int{12, 34},
+		MinGrade:      "k",
+		MaxGrade:      "1",
+		ResourceTypes: []string{"video", "game"},
+		Limit:         20,
+	}
+	if err := q.Validate(); err != nil {
+		t.Fatalf("Valid query rejected: %+v", err)
+	}
+	want := "descriptive=counting&grades_range=K-1&limit=20&resource_types=video%2Cgame&standard_ids=12%2C34"
+	if got := q.Encode(); got != want {
+		t.Errorf("Query encoded as %s, expected %s", got, want)
+	}
+	if got := (SearchQuery{MaxGrade: "3"}).Encode(); got != "grades_range=3-3" {
+		t.Errorf("Single grade encoded as %s", got)
+	}
+}
+
+func TestSearchQueryValidate(t *testing.T) {
+	for _, q := range []SearchQuery{
+		{MinGrade: "13"},
+		{MinGrade: "3", MaxGrade: "K"},
+		{StandardIDs: []int{0}},
+		{ResourceTypes: []string{" "}},
+		{Sort: "popular"},
+		{Limit: maxPageSize + 1},
+		{Offset: -1},
+	} {
+		if q.Validate() == nil {
+			t.Errorf("Invalid query %+v accepted", q)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// A RateLimiter is a token bucket which lets requests through at a steady
+// rate with bursts of up to its burst size.  It is safe for concurrent use;
+// clients sharing a RateLimiter share its quota.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// until is when the partner said its quota resets; nothing is let
+	// through before then.
+	until time.Time
+}
+
+// NewRateLimiter returns a RateLimiter letting rate requests a second through
+// with bursts of up to burst requests.  It starts full.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// refill adds the tokens earned since the last call.  l.mu must be held.
+func (l *RateLimiter) refill(now time.Time) {
+	if now.Before(l.until) {
+		l.last = now
+		return
+	}
+	if l.last.Before(l.until) {
+		l.last = l.until
+	}
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// Wait blocks until a request may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.refill(now)
+		var wait time.Duration
+		switch {
+		case now.Before(l.until):
+			wait = l.until.Sub(now)
+		case l.tokens >= 1:
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		case l.rate <= 0:
+			l.mu.Unlock()
+			return nil
+		default:
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// pause lets nothing through until t.
+func (l *RateLimiter) pause(t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.After(l.until) {
+		l.refill(time.Now())
+		l.until = t
+	}
+}
+
+// observe adjusts the bucket to the quota the partner reports in the
+// X-RateLimit-Remaining and X-RateLimit-Reset headers of resp, and to the
+// Retry-After of a 429.
+func (l *RateLimiter) observe(resp *http.Response) {
+	now := time.Now()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if d, ok := retryAfter(resp); ok {
+			l.pause(now.Add(d))
+		}
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	if remaining <= 0 {
+		if reset, ok := rateLimitReset(resp.Header.Get("X-RateLimit-Reset"), now); ok {
+			glog.V(1).Infof("Partner quota used up, waiting until %s", reset)
+			l.pause(reset)
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(now)
+	if float64(remaining) < l.tokens {
+		l.tokens = float64(remaining)
+	}
+}
+
+// rateLimitReset parses an X-RateLimit-Reset header, which is either a Unix
+// time or a number of seconds from now.
+func rateLimitReset(v string, now time.Time) (time.Time, bool) {
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, false
+	}
+	// anything before 2001 must be relative
+	if secs < 1e9 {
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+	return time.Unix(secs, 0), true
+}
+
+// RateLimits say how fast a Client may send requests.
+type RateLimits struct {
+	// Default limits every request.  If nil only Endpoints are limited.
+	Default *RateLimiter
+	// Endpoints limit requests whose path starts with their key, such as
+	// /1/resources, as well.  Only the longest matching key is used.
+	Endpoints map[string]*RateLimiter
+	// Adaptive makes the limiters slow down to the quota reported in the
+	// partner's X-RateLimit-Remaining and X-RateLimit-Reset headers and
+	// stop after a 429 for as long as its Retry-After says.
+	Adaptive bool
+}
+
+// limiters returns the limiters applying to path.
+func (r *RateLimits) limiters(path string) []*RateLimiter {
+	var ls []*RateLimiter
+	if r.Default != nil {
+		ls = append(ls, r.Default)
+	}
+	best := ""
+	for prefix := range r.Endpoints {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if l := r.Endpoints[best]; best != "" && l != nil {
+		ls = append(ls, l)
+	}
+	return ls
+}
+
+// wait blocks until every limiter for path lets a request through.
+func (r *RateLimits) wait(ctx context.Context, path string) error {
+	for _, l := range r.limiters(path) {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observe adapts the limiters for path to resp if r is Adaptive.
+func (r *RateLimits) observe(path string, resp *http.Response) {
+	if !r.Adaptive {
+		return
+	}
+	for _, l := range r.limiters(path) {
+		l.observe(resp)
+	}
+}
+
+// transport returns a RoundTripper which holds requests to next, or
+// http.DefaultTransport if next is nil, back to the limits for their path
+// below root, the path of the partner API root.  A Client puts it below its
+// Cache, so cached responses use no quota and are served while it waits.
+func (r *RateLimits) transport(root string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &rateLimitTransport{limits: r, root: strings.TrimSuffix(root, "/"), next: next}
+}
+
+type rateLimitTransport struct {
+	limits *RateLimits
+	root   string
+	next   http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, t.root)
+	if err := t.limits.wait(req.Context(), path); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		t.limits.observe(path, resp)
+	}
+	return resp, err
+}
@@ -0,0 +1,114 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(100, 2)
+	ctx := context.Background()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(ctx); err != nil {
+				t.Errorf("Error from Wait: %+v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	// a burst of 2, then 2 more at 10ms each
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Errorf("4 requests at 100 a second with a burst of 2 took %s", d)
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	if reset, ok := rateLimitReset("30", now); !ok || !reset.Equal(now.Add(30*time.Second)) {
+		t.Errorf("Relative reset is %s", reset)
+	}
+	if reset, ok := rateLimitReset("1500000060", now); !ok || !reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("Absolute reset is %s", reset)
+	}
+	if _, ok := rateLimitReset("soon", now); ok {
+		t.Errorf("Bad reset parsed")
+	}
+}
+
+// TestRateLimits checks a client limits each endpoint and adapts to the partner's quota headers
+func TestRateLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/1/standard_groups.json" {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "60")
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	resources := NewRateLimiter(1000, 1)
+	groups := NewRateLimiter(1000, 5)
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RateLimits = &RateLimits{
+		Endpoints: map[string]*RateLimiter{"/1/": groups, "/1/resources": resources},
+		Adaptive:  true,
+	}
+	if ls := c.RateLimits.limiters("/1/resources/1.json"); len(ls) != 1 || ls[0] != resources {
+		t.Errorf("Wrong limiters for a resource")
+	}
+
+	ctx := context.Background()
+	if _, err := c.GetResource(ctx, 1); err != nil {
+		t.Errorf("Error from GetResource: %+v", err)
+	}
+	if _, err := c.ListStandardGroups(ctx); err != nil {
+		t.Errorf("Error from ListStandardGroups: %+v", err)
+	}
+	// the partner said the quota is used up for the next minute
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListGradeGroups(ctx, 1); err == nil {
+		t.Errorf("Request sent with the quota used up")
+	}
+	if _, err := c.GetResource(context.Background(), 2); err != nil {
+		t.Errorf("Error from GetResource: %+v", err)
+	}
+}
+
+// TestRateLimitsBelowCache checks cached responses use no quota and are served
+// while the quota is used up
+func TestRateLimitsBelowCache(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Write([]byte(`{"standard_groups":[{"id":1}]}`))
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.Cache = NewCache(NewMemoryCache(10))
+	c.RateLimits = &RateLimits{Endpoints: map[string]*RateLimiter{"/1/": NewRateLimiter(0.001, 1)}}
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := c.ListStandardGroups(ctx)
+		cancel()
+		if err != nil {
+			t.Errorf("Error from ListStandardGroups: %+v", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListGradeGroups(ctx, 1); err == nil || hits != 1 {
+		t.Errorf("Got error %+v after %d requests with the quota used up", err, hits)
+	}
+}
@@ -0,0 +1,147 @@
+package opened
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// TenantConfig holds the partner credentials of one tenant, such as a district.
+type TenantConfig struct {
+	// BaseURL is the partner API root.  If empty the registry's BaseURL is used.
+	BaseURL  string `json:"base_url,omitempty"`
+	ClientID string `json:"client_id"`
+	Secret   string `json:"secret"`
+	Username string `json:"username"`
+}
+
+// RegistryConfig is the contents of a registry config file, e.g.
+//
+//	{
+//	  "base_url": "https://partner.opened.com",
+//	  "tenants": {
+//	    "springfield": {"client_id": "abc", "secret": "${SPRINGFIELD_SECRET}", "username": "admin@springfield.k12.us"}
+//	  }
+//	}
+type RegistryConfig struct {
+	BaseURL string                  `json:"base_url"`
+	Tenants map[string]TenantConfig `json:"tenants"`
+}
+
+// A Registry hands out a Client for each tenant, creating it on first use.
+// Each tenant's client has its own credentials and token cache.  A Registry is
+// safe for concurrent use.
+type Registry struct {
+	// Configure, if set, is called with each new client before it is handed
+	// out, e.g. to set Middleware or share RateLimits between tenants.
+	Configure func(tenant string, c *Client)
+
+	mu      sync.Mutex
+	config  RegistryConfig
+	clients map[string]*Client
+}
+
+// NewRegistry returns a Registry for the tenants in config.
+func NewRegistry(config RegistryConfig) *Registry {
+	r := &Registry{config: RegistryConfig{BaseURL: config.BaseURL, Tenants: map[string]TenantConfig{}}, clients: map[string]*Client{}}
+	for tenant, tc := range config.Tenants {
+		r.config.Tenants[tenant] = tc
+	}
+	return r
+}
+
+// LoadRegistry returns a Registry for the JSON config file at path.
+// Values which are exactly ${VAR} are replaced with the environment variable
+// VAR so secrets need not be kept in the file.  Other values are used as they
+// are, even if they contain $.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config RegistryConfig
+	if err = json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("opened: reading %s: %v", path, err)
+	}
+	config.BaseURL = expandEnv(config.BaseURL)
+	for tenant, tc := range config.Tenants {
+		config.Tenants[tenant] = TenantConfig{
+			BaseURL:  expandEnv(tc.BaseURL),
+			ClientID: expandEnv(tc.ClientID),
+			Secret:   expandEnv(tc.Secret),
+			Username: expandEnv(tc.Username),
+		}
+	}
+	glog.V(1).Infof("Loaded %d tenants from %s", len(config.Tenants), path)
+	return NewRegistry(config), nil
+}
+
+// envRef matches a config value which names an environment variable.
+var envRef = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// expandEnv returns the environment variable v names, if it is ${VAR}, or
+// else v itself.
+func expandEnv(v string) string {
+	if m := envRef.FindStringSubmatch(v); m != nil {
+		return os.Getenv(m[1])
+	}
+	return v
+}
+
+// Client returns the client for tenant, creating it if needed.
+func (r *Registry) Client(tenant string) (*Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clients[tenant]; ok {
+		return c, nil
+	}
+	tc, ok := r.config.Tenants[tenant]
+	if !ok {
+		return nil, fmt.Errorf("opened: unknown tenant %q", tenant)
+	}
+	baseURL := tc.BaseURL
+	if baseURL == "" {
+		baseURL = r.config.BaseURL
+	}
+	c := NewClient(baseURL, tc.ClientID, tc.Secret, tc.Username)
+	if r.Configure != nil {
+		r.Configure(tenant, c)
+	}
+	r.clients[tenant] = c
+	return c, nil
+}
+
+// Set adds or replaces the credentials of tenant.  A client already created
+// for tenant is dropped, along with its tokens.
+func (r *Registry) Set(tenant string, tc TenantConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config.Tenants[tenant] = tc
+	delete(r.clients, tenant)
+}
+
+// Remove drops tenant and its client.
+func (r *Registry) Remove(tenant string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.config.Tenants, tenant)
+	delete(r.clients, tenant)
+}
+
+// Tenants lists the registered tenants in order.
+func (r *Registry) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenants := make([]string, 0, len(r.config.Tenants))
+	for tenant := range r.config.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
@@ -0,0 +1,82 @@
+package opened
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestRegistry checks tenants get their own lazily created clients and tokens
+func TestRegistry(t *testing.T) {
+	var issued int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == tokenPath {
+			atomic.AddInt32(&issued, 1)
+			w.Write([]byte(`{"access_token":"token-` + r.FormValue("client_id") + `-` + r.FormValue("secret") + `"}`))
+			return
+		}
+		w.Write([]byte(`{"standard_groups":[]}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("NORTH_SECRET", "north-secret")
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	config := `{
+		"base_url": "` + ts.URL + `",
+		"tenants": {
+			"north": {"client_id": "north", "secret": "${NORTH_SECRET}", "username": "n"},
+			"south": {"client_id": "south", "secret": "south-secret", "username": "s"},
+			"east": {"client_id": "east", "secret": "abc$def$1x", "username": "${NORTH_SECRET}e"}
+		}
+	}`
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("Error from LoadRegistry: %+v", err)
+	}
+	var configured []string
+	r.Configure = func(tenant string, c *Client) {
+		configured = append(configured, tenant)
+	}
+	if tenants := r.Tenants(); !reflect.DeepEqual(tenants, []string{"east", "north", "south"}) {
+		t.Errorf("Got tenants %v", tenants)
+	}
+	if east := r.config.Tenants["east"]; east.Secret != "abc$def$1x" || east.Username != "${NORTH_SECRET}e" {
+		t.Errorf("Values which are not exactly ${VAR} were expanded: %+v", east)
+	}
+
+	ctx := context.Background()
+	for _, tenant := range []string{"north", "south", "north"} {
+		c, err := r.Client(tenant)
+		if err != nil {
+			t.Fatalf("Error from Client: %+v", err)
+		}
+		tok, err := c.GetToken(ctx)
+		if err != nil || tok != "token-"+tenant+"-"+tenant+"-secret" {
+			t.Errorf("Got token %s for %s, error %+v", tok, tenant, err)
+		}
+	}
+	if issued != 2 {
+		t.Errorf("%d tokens issued", issued)
+	}
+	if !reflect.DeepEqual(configured, []string{"north", "south"}) {
+		t.Errorf("Configured %v", configured)
+	}
+
+	r.Set("south", TenantConfig{ClientID: "south", Secret: "rotated", Username: "s"})
+	c, _ := r.Client("south")
+	if tok, _ := c.GetToken(ctx); tok != "token-south-rotated" {
+		t.Errorf("Got token %s after rotating the secret", tok)
+	}
+	if _, err = r.Client("west"); err == nil || !strings.Contains(err.Error(), "west") {
+		t.Errorf("Got error %+v for unknown tenant", err)
+	}
+}
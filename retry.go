@@ -0,0 +1,127 @@
+package opened
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A RetryPolicy says how partner API requests that fail with a transient
+// error (429, 502, 503, 504 or a network error) are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries including the first.  Values below 1 mean 1.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry.  It doubles for every
+	// following retry up to MaxBackoff, and each wait is jittered.
+	MinBackoff time.Duration
+	// MaxBackoff also bounds the wait asked for by a Retry-After header: a
+	// response asking for longer is returned without retrying.  If 0 waits
+	// are not bounded.
+	MaxBackoff time.Duration
+	// RetryNonIdempotent allows requests such as POST to be retried too.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is used by clients without a RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// NoRetry is a RetryPolicy which never retries.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context which makes client calls made with it use
+// p instead of the client's RetryPolicy.
+func WithRetryPolicy(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+// retryPolicy returns the policy for a call made with ctx.
+func (c *Client) retryPolicy(ctx context.Context) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return p
+	}
+	if c.RetryPolicy != nil {
+		return *c.RetryPolicy
+	}
+	return DefaultRetryPolicy
+}
+
+// attempts returns how many tries the policy allows for method.
+func (p RetryPolicy) attempts(method string) int {
+	if p.MaxAttempts < 1 || !(p.RetryNonIdempotent || idempotent(method)) {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the jittered wait before retry number n (starting at 1).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < n && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// wait somewhere between half and all of d so parallel jobs spread out
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// retryableStatus reports whether a response with status code is worth retrying.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the wait asked for by the response's Retry-After header,
+// given either in seconds or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if _, ok := retryAfter(resp); ok {
+		t.Errorf("Retry-After found in empty header")
+	}
+	resp.Header.Set("Retry-After", "2")
+	if d, ok := retryAfter(resp); !ok || d != 2*time.Second {
+		t.Errorf("Retry-After 2 parsed as %s", d)
+	}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if d, ok := retryAfter(resp); !ok || d < 59*time.Minute {
+		t.Errorf("Retry-After date parsed as %s", d)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, MinBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for n, max := range []time.Duration{100, 200, 300, 300} {
+		max *= time.Millisecond
+		if d := p.backoff(n + 1); d < max/2 || d > max {
+			t.Errorf("Backoff %d is %s, expected between %s and %s", n+1, d, max/2, max)
+		}
+	}
+	if p.attempts("POST") != 1 || p.attempts("GET") != 5 {
+		t.Errorf("POST gets %d attempts and GET %d", p.attempts("POST"), p.attempts("GET"))
+	}
+}
+
+// TestRetry checks that transient failures are retried and counted
+func TestRetry(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch {
+		case r.URL.Path == "/1/grade_groups.json":
+			w.WriteHeader(http.StatusBadGateway)
+		case n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case n == 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"standard_groups":[{"id":1}]}`))
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+
+	groups, err := c.ListStandardGroups(context.Background())
+	if err != nil || len(groups.StandardGroups) != 1 || calls != 3 {
+		t.Errorf("Got %+v, %+v after %d calls", groups, err, calls)
+	}
+
+	calls = 0
+	ctx := WithRetryPolicy(context.Background(), RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond})
+	_, err = c.ListGradeGroups(ctx, 1)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusBadGateway || apiErr.Attempts != 2 || calls != 2 {
+		t.Errorf("Got %+v after %d calls", err, calls)
+	}
+}
+
+// TestRetryAfterTooLong checks a Retry-After beyond MaxBackoff is not waited for
+func TestRetryAfterTooLong(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	c.RetryPolicy = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	start := time.Now()
+	_, err := c.ListStandardGroups(context.Background())
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Attempts != 1 || calls != 1 {
+		t.Errorf("Got %+v after %d calls", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Waited %s for a Retry-After beyond MaxBackoff", elapsed)
+	}
+}
@@ -0,0 +1,65 @@
+package opened
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/golang/glog"
+)
+
+// A Category groups the standards of a grade group, such as Counting and Cardinality
+type Category struct {
+	ID           int
+	Title        string
+	GradeGroupID int `json:"grade_group_id"`
+}
+
+// CategoryList is structure get back list of categories
+type CategoryList struct {
+	Categories []Category `json:"categories"`
+}
+
+// StandardList is structure get back list of standards
+type StandardList struct {
+	Standards []Standard `json:"standards"`
+}
+
+// ListCategories lists the categories of the grade group with the given ID
+func (c *Client) ListCategories(ctx context.Context, gradeGroupID int) (CategoryList, error) {
+	p := url.Values{}
+	p.Set("grade_group", strconv.Itoa(gradeGroupID))
+	categories := CategoryList{}
+	if err := c.get(ctx, "/1/categories.json", p, &categories); err != nil {
+		glog.Errorf("Error listing categories: %+v", err)
+		return CategoryList{}, err
+	}
+	glog.V(2).Infof("Categories: %+v", categories)
+	return categories, nil
+}
+
+// ListStandards lists the standards in the category with the given ID
+func (c *Client) ListStandards(ctx context.Context, categoryID int) (StandardList, error) {
+	p := url.Values{}
+	p.Set("category", strconv.Itoa(categoryID))
+	standards := StandardList{}
+	if err := c.get(ctx, "/1/standards.json", p, &standards); err != nil {
+		glog.Errorf("Error listing standards: %+v", err)
+		return StandardList{}, err
+	}
+	glog.V(2).Infof("Standards: %+v", standards)
+	return standards, nil
+}
+
+// GetStandard fetches the standard with the given identifier, such as K.CC.1, from the partner API
+func (c *Client) GetStandard(ctx context.Context, identifier string) (Standard, error) {
+	var data struct {
+		Standard Standard `json:"standard"`
+	}
+	if err := c.get(ctx, "/1/standards/"+url.PathEscape(identifier)+".json", nil, &data); err != nil {
+		glog.Errorf("Error retrieving standard %s: %+v", identifier, err)
+		return Standard{}, err
+	}
+	glog.V(3).Infof("Standard is: %+v", data.Standard)
+	return data.Standard, nil
+}
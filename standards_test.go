@@ -0,0 +1,54 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWalkStandards walks standard group -> grade group -> category -> standard
+func TestWalkStandards(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/1/standard_groups.json?":
+			w.Write([]byte(`{"standard_groups":[{"id":1,"title":"Common Core Math"}]}`))
+		case "/1/grade_groups.json?standard_group=1":
+			w.Write([]byte(`{"grade_groups":[{"id":2,"title":"Kindergarten","grades_range":"K"}]}`))
+		case "/1/categories.json?grade_group=2":
+			w.Write([]byte(`{"categories":[{"id":3,"title":"Counting and Cardinality","grade_group_id":2}]}`))
+		case "/1/standards.json?category=3":
+			w.Write([]byte(`{"standards":[{"id":4,"identifier":"K.CC.1","grade":"K","title":"Count to 100"}]}`))
+		case "/1/standards/K.CC.1.json?":
+			w.Write([]byte(`{"standard":{"id":4,"identifier":"K.CC.1","grade":"K","title":"Count to 100","description":"Count to 100 by ones and by tens."}}`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(ts.URL, "", "", "")
+	c.TokenSource = StaticTokenSource("token")
+	ctx := context.Background()
+
+	groups, err := c.ListStandardGroups(ctx)
+	if err != nil {
+		t.Fatalf("Error from ListStandardGroups: %+v", err)
+	}
+	gradeGroups, err := c.ListGradeGroups(ctx, groups.StandardGroups[0].ID)
+	if err != nil {
+		t.Fatalf("Error from ListGradeGroups: %+v", err)
+	}
+	categories, err := c.ListCategories(ctx, gradeGroups.GradeGroups[0].ID)
+	if err != nil || len(categories.Categories) != 1 {
+		t.Fatalf("Got categories %+v, error %+v", categories, err)
+	}
+	standards, err := c.ListStandards(ctx, categories.Categories[0].ID)
+	if err != nil || len(standards.Standards) != 1 {
+		t.Fatalf("Got standards %+v, error %+v", standards, err)
+	}
+	s, err := c.GetStandard(ctx, standards.Standards[0].Identifier)
+	if err != nil || s.ID != 4 || s.Grade != "K" || s.Description == "" {
+		t.Errorf("Got standard %+v, error %+v", s, err)
+	}
+}
@@ -0,0 +1,171 @@
+package opened
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// expiryDelta is how long before its expiry a token is refreshed, so that a
+// token never runs out in the middle of a request.
+const expiryDelta = time.Minute
+
+// A Token is an OAuth access token handed out by the partner API.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the token is set and not about to expire.
+// A token without an expiry never expires.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// A TokenSource supplies tokens for partner API calls.  It must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// invalidator is implemented by token sources that can drop a token the
+// partner API has rejected.
+type invalidator interface {
+	Invalidate(accessToken string)
+}
+
+// StaticTokenSource returns a TokenSource that always returns the same access token.
+func StaticTokenSource(accessToken string) TokenSource {
+	return staticTokenSource{&Token{AccessToken: accessToken}}
+}
+
+type staticTokenSource struct {
+	tok *Token
+}
+
+func (s staticTokenSource) Token(ctx context.Context) (*Token, error) {
+	return s.tok, nil
+}
+
+// cachingTokenSource hands out the cached token until it is close to expiry
+// and then fetches a new one, using the refresh token if there is one.
+type cachingTokenSource struct {
+	mu    sync.Mutex
+	tok   *Token
+	fetch func(ctx context.Context, refreshToken string) (*Token, error)
+}
+
+// Token returns a copy of the cached token, fetching a new one if it is
+// missing or expired, so callers cannot change the cache.
+func (s *cachingTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok.Valid() {
+		tok := *s.tok
+		return &tok, nil
+	}
+	refreshToken := ""
+	if s.tok != nil {
+		refreshToken = s.tok.RefreshToken
+	}
+	tok, err := s.fetch(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	s.tok = tok
+	cp := *tok
+	return &cp, nil
+}
+
+// Invalidate forces the next call to Token to fetch a new token, unless the
+// rejected token has already been replaced by another goroutine.
+func (s *cachingTokenSource) Invalidate(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok != nil && s.tok.AccessToken == accessToken {
+		s.tok = &Token{RefreshToken: s.tok.RefreshToken}
+	}
+}
+
+// tokenResponse is the body returned by /1/oauth/get_token
+type tokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    json.Number `json:"expires_in"`
+}
+
+// newPartnerTokenSource returns a caching TokenSource which gets tokens for the
+// client's credentials from the partner token endpoint at uri.
+func newPartnerTokenSource(c *Client, uri string) *cachingTokenSource {
+	return &cachingTokenSource{fetch: func(ctx context.Context, refreshToken string) (*Token, error) {
+		if refreshToken != "" {
+			tok, err := c.requestToken(ctx, uri, refreshToken)
+			if err == nil {
+				return tok, nil
+			}
+			glog.V(1).Infof("Refreshing token for %s failed, requesting a new one: %+v", c.ClientID, err)
+		}
+		return c.requestToken(ctx, uri, "")
+	}}
+}
+
+// requestToken posts the client's credentials (and refreshToken if set) to uri.
+func (c *Client) requestToken(ctx context.Context, uri string, refreshToken string) (*Token, error) {
+	v := url.Values{}
+	v.Set("client_id", c.ClientID)
+	v.Set("secret", c.Secret)
+	v.Set("username", c.Username)
+	if refreshToken != "" {
+		v.Set("grant_type", "refresh_token")
+		v.Set("refresh_token", refreshToken)
+	}
+	glog.V(1).Infof("Getting token for %s", c.ClientID)
+	glog.V(1).Infof("To URL %s", uri)
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(tokenPath, resp, body)
+	}
+	var data tokenResponse
+	if err = json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	if data.AccessToken == "" {
+		return nil, errors.New("opened: no access_token in token response")
+	}
+	tok := &Token{
+		AccessToken:  data.AccessToken,
+		TokenType:    data.TokenType,
+		RefreshToken: data.RefreshToken,
+	}
+	if secs, err := data.ExpiresIn.Int64(); err == nil && secs > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(secs) * time.Second)
+	}
+	glog.V(2).Infof("Token for %s expires at %s", c.ClientID, tok.Expiry)
+	return tok, nil
+}
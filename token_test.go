@@ -0,0 +1,85 @@
+package opened
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenValid(t *testing.T) {
+	var tok *Token
+	if tok.Valid() {
+		t.Errorf("nil token is valid")
+	}
+	tok = &Token{AccessToken: "abc"}
+	if !tok.Valid() {
+		t.Errorf("token without expiry is not valid")
+	}
+	tok.Expiry = time.Now().Add(expiryDelta / 2)
+	if tok.Valid() {
+		t.Errorf("token about to expire is valid")
+	}
+}
+
+// TestTokenRefresh checks that tokens are cached, refreshed on expiry and
+// replaced when the partner rejects them
+func TestTokenRefresh(t *testing.T) {
+	var issued, rejected int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case tokenPath:
+			n := atomic.AddInt32(&issued, 1)
+			if n > 1 && r.FormValue("refresh_token") != "refresh" {
+				t.Errorf("refresh token not sent: %q", r.FormValue("refresh_token"))
+			}
+			w.Write([]byte(`{"access_token":"token` + strconv.Itoa(int(n)) + `","expires_in":3600,"refresh_token":"refresh"}`))
+		case "/1/standard_groups.json":
+			// the first token is revoked on the server
+			if r.Header.Get("Authorization") == "Bearer token1" {
+				atomic.AddInt32(&rejected, 1)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"standard_groups":[{"id":1}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, "id", "secret", "user")
+	for i := 0; i < 3; i++ {
+		groups, err := c.ListStandardGroups(context.Background())
+		if err != nil {
+			t.Fatalf("Error from ListStandardGroups: %+v", err)
+		}
+		if len(groups.StandardGroups) != 1 {
+			t.Errorf("Got %d groups", len(groups.StandardGroups))
+		}
+	}
+	if issued != 2 || rejected != 1 {
+		t.Errorf("Issued %d tokens with %d rejected, expected 2 and 1", issued, rejected)
+	}
+
+	tok, err := c.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get token: %+v", err)
+	}
+	if tok.Expiry.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Token expiry %s not taken from expires_in", tok.Expiry)
+	}
+	// changing the returned token leaves the cached one alone
+	tok.AccessToken = "changed"
+	if tok, _ = c.Token(context.Background()); tok.AccessToken != "token2" {
+		t.Errorf("Cached token changed through a returned copy, got %s", tok.AccessToken)
+	}
+	s := c.tokenSource().(*cachingTokenSource)
+	s.mu.Lock()
+	s.tok.Expiry = time.Now()
+	s.mu.Unlock()
+	if tok, _ = c.Token(context.Background()); tok.AccessToken != "token3" {
+		t.Errorf("Expired token not refreshed, got %s", tok.AccessToken)
+	}
+}
@@ -0,0 +1,172 @@
+package opened_test
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/glog"
+	opened "github.com/openedinc/opened-go"
+	"github.com/openedinc/opened-go/openedtest"
+)
+
+// setupWs starts a fake partner and points the environment at it for the
+// package calls which use web services (instead of database)
+func setupWs(t *testing.T) (*openedtest.Server, string) {
+	flag.Set("alsologtostderr", "true")
+	flag.Set("v", "3")
+	server := openedtest.NewServer(openedtest.DefaultFixtures())
+	t.Cleanup(server.Close)
+	t.Setenv("PARTNER_BASE_URI", server.URL)
+	t.Setenv("CLIENT_ID", openedtest.ClientID)
+	t.Setenv("CLIENT_SECRET", openedtest.Secret)
+	t.Setenv("USERNAME", openedtest.Username)
+	token, err := opened.GetToken("", "", "", "")
+	if err != nil {
+		t.Fatalf("Failed to get token: %+v", err)
+	}
+	return server, token
+}
+
+func TestGetToken(t *testing.T) {
+	_, token := setupWs(t)
+	if token == "" {
+		t.Errorf("Got empty token")
+	}
+	glog.V(1).Infof("Got token %s", token)
+	if _, err := opened.GetToken("", "wrong", "", ""); !opened.IsUnauthorized(err) {
+		t.Errorf("Expected unauthorized error, got %+v", err)
+	}
+}
+
+func TestListStandardGroups(t *testing.T) {
+	_, token := setupWs(t)
+	results, err := opened.ListStandardGroups(token)
+	if err != nil {
+		t.Errorf("Error from ListStandardGroups: %+v", err)
+	}
+	if len(results.StandardGroups) != 2 {
+		t.Errorf("%d groups returned", len(results.StandardGroups))
+	}
+}
+
+func TestListGradeGroups(t *testing.T) {
+	_, token := setupWs(t)
+	sgResults, err := opened.ListStandardGroups(token)
+	if err != nil {
+		t.Fatalf("Error from ListStandardGroups: %+v", err)
+	}
+	results, err := opened.ListGradeGroups(sgResults.StandardGroups[0].ID, token)
+	if err != nil {
+		t.Errorf("Error from ListGradeGroups: %+v", err)
+	}
+	if len(results.GradeGroups) != 2 {
+		t.Errorf("%d grade groups returned", len(results.GradeGroups))
+	}
+}
+
+// TestSearchResources calls SearchResources with some query parameters and checks if it gets back results
+func TestSearchResources(t *testing.T) {
+	_, token := setupWs(t)
+	queryParams := make(map[string]string)
+	queryParams["descriptive"] = "counting"
+	queryParams["grades_range"] = "K-1"
+	results, err := opened.SearchResources(queryParams, token)
+	if err != nil {
+		t.Fatalf("Error from SearchResources: %+v", err)
+	}
+	if len(results.Resources) != 2 {
+		t.Fatalf("%d results returned", len(results.Resources))
+	}
+	glog.V(2).Infof("First result: %+v", results.Resources[0])
+}
+
+// TestSearchUpdatedSince checks the fake partner keeps resources updated at or
+// after updated_since, as the mirror package assumes
+func TestSearchUpdatedSince(t *testing.T) {
+	since := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := since.Add(-time.Second), since.Add(time.Second)
+	f := openedtest.DefaultFixtures()
+	f.Resources = f.Resources[:3]
+	f.Resources[0].UpdatedAt = &before
+	f.Resources[1].UpdatedAt = &since
+	f.Resources[2].UpdatedAt = &after
+	server := openedtest.NewServer(f)
+	defer server.Close()
+
+	results, err := server.Client().SearchResources(context.Background(), map[string]string{"updated_since": since.Format(time.RFC3339)})
+	if err != nil {
+		t.Fatalf("Error from SearchResources: %+v", err)
+	}
+	if len(results.Resources) != 2 || results.Resources[0].ID != f.Resources[1].ID {
+		t.Errorf("Got resources %+v", results.Resources)
+	}
+}
+
+// TestInjectedFailures checks the client against failures injected into the fake partner
+func TestInjectedFailures(t *testing.T) {
+	server := openedtest.NewServer(openedtest.DefaultFixtures())
+	defer server.Close()
+	c := server.Client()
+	c.RetryPolicy = &opened.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	ctx := context.Background()
+
+	server.Fail("/1/standard_groups.json", http.StatusTooManyRequests, 2)
+	if _, err := c.ListStandardGroups(ctx); err != nil {
+		t.Errorf("Rate limited request not retried: %+v", err)
+	}
+
+	server.Fail("/1/standard_groups.json", http.StatusInternalServerError, 1)
+	if _, err := c.ListStandardGroups(ctx); err == nil {
+		t.Errorf("Expected error from failing partner")
+	}
+
+	server.RevokeTokens()
+	if _, err := c.ListStandardGroups(ctx); err != nil {
+		t.Errorf("Revoked token not replaced: %+v", err)
+	}
+	if n := server.Requests("/1/oauth/get_token"); n != 2 {
+		t.Errorf("%d tokens requested", n)
+	}
+
+	server.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListStandardGroups(ctx); err == nil {
+		t.Errorf("Slow partner did not time out")
+	}
+}
+
+// TestReplayCatalog replays the session in testdata/catalog.json.  That file is
+// a synthetic fixture recorded against openedtest.NewServer with
+// DefaultFixtures, not the real partner, so it checks the client's requests
+// and decoding rather than the partner's own payloads.  Run with
+// OPENED_RECORD=1 and partner credentials in the environment to replace it
+// with a real recording, adjusting the expectations below to match.
+func TestReplayCatalog(t *testing.T) {
+	base := os.Getenv("PARTNER_BASE_URI")
+	if base == "" {
+		base = "https://partner.opened.com"
+	}
+	c := opened.NewClient(base, os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"), os.Getenv("USERNAME"))
+	c.HTTPClient = &http.Client{Transport: openedtest.Transport(t, "testdata/catalog.json")}
+	ctx := context.Background()
+
+	results, err := c.SearchResources(ctx, map[string]string{"descriptive": "counting", "grades_range": "K-1"})
+	if err != nil {
+		t.Fatalf("Error from SearchResources: %+v", err)
+	}
+	glog.V(1).Infof("%d results returned", len(results.Resources))
+	groups, err := c.ListStandardGroups(ctx)
+	if err != nil || len(groups.StandardGroups) == 0 {
+		t.Fatalf("Got groups %+v, error %+v", groups, err)
+	}
+	gradeGroups, err := c.ListGradeGroups(ctx, groups.StandardGroups[0].ID)
+	if err != nil {
+		t.Fatalf("Error from ListGradeGroups: %+v", err)
+	}
+	glog.V(1).Infof("%d grade groups returned", len(gradeGroups.GradeGroups))
+}
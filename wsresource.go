@@ -0,0 +1,74 @@
+package opened
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// wsResourceFields holds the lower-cased JSON names of the fields mapped by WsResource.
+var wsResourceFields = jsonFieldNames(reflect.TypeOf(WsResource{}))
+
+// jsonFieldNames returns the lower-cased JSON keys encoding/json maps onto struct type t.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+// wsResource has the fields of WsResource without its JSON methods.
+type wsResource WsResource
+
+// UnmarshalJSON decodes a partner resource payload, keeping fields WsResource
+// does not know about in Extra.
+func (r *WsResource) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*wsResource)(r)); err != nil {
+		return err
+	}
+	r.Extra = nil
+	for k, v := range fields {
+		if wsResourceFields[strings.ToLower(k)] {
+			continue
+		}
+		if r.Extra == nil {
+			r.Extra = map[string]json.RawMessage{}
+		}
+		r.Extra[k] = v
+	}
+	return nil
+}
+
+// MarshalJSON encodes the resource including any fields kept in Extra.
+func (r WsResource) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(wsResource(r))
+	if err != nil || len(r.Extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range r.Extra {
+		if _, ok := fields[k]; !ok {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
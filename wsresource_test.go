@@ -0,0 +1,46 @@
+package opened
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const resourcePayload = `{
+	"id": 7,
+	"title": "Counting to 10",
+	"grades_range": "K-1",
+	"standards": [{"id": 12, "identifier": "K.CC.1", "title": "Count to 100"}],
+	"thumbnail": "http://example.com/7.png",
+	"rating": 4.5,
+	"subjects": ["Math"],
+	"duration": 95,
+	"premium": true,
+	"updated_at": "2016-05-10T17:54:28.000Z",
+	"new_field": {"nested": [1, 2]}
+}`
+
+func TestWsResourceJSON(t *testing.T) {
+	var r WsResource
+	if err := json.Unmarshal([]byte(resourcePayload), &r); err != nil {
+		t.Fatalf("Failed to decode resource: %+v", err)
+	}
+	if r.ID != 7 || r.GradesRange != "K-1" || len(r.Standards) != 1 || r.Standards[0].Identifier != "K.CC.1" ||
+		r.Rating != 4.5 || r.Duration != 95 || !r.Premium || r.UpdatedAt == nil {
+		t.Errorf("Resource decoded as %+v", r)
+	}
+	if len(r.Extra) != 1 || string(r.Extra["new_field"]) != `{"nested": [1, 2]}` {
+		t.Errorf("Unknown fields kept as %+v", r.Extra)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Failed to encode resource: %+v", err)
+	}
+	var again WsResource
+	if err = json.Unmarshal(data, &again); err != nil {
+		t.Fatalf("Failed to decode encoded resource: %+v", err)
+	}
+	if again.Title != r.Title || string(again.Extra["new_field"]) != `{"nested":[1,2]}` {
+		t.Errorf("Resource did not survive a round trip: %s", data)
+	}
+}